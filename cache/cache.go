@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"buda-challenge/dto"
+	"container/list"
+	"sync"
+)
+
+// Key identifies a query against one network version. Options distinguishes
+// queries with the same stations and color but different settings.
+type Key struct {
+	Version        string
+	InitialStation string
+	FinalStation   string
+	TrainColor     string
+	Options        string
+}
+
+// Entry is a cached answer: either a result or the error message returned
+// for the query.
+type Entry struct {
+	Result dto.Result
+	Error  string
+}
+
+type Stats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int
+}
+
+// LRU is a size-bounded, least recently used cache of route results. Entries
+// of older network versions are dropped as soon as a newer version is
+// added. It is safe for concurrent use.
+type LRU struct {
+	size    int
+	mutex   sync.Mutex
+	version string
+	order   *list.List
+	items   map[Key]*list.Element
+	hits    uint64
+	misses  uint64
+}
+
+type item struct {
+	key   Key
+	entry Entry
+}
+
+func NewLRU(size int) *LRU {
+	return &LRU{
+		size:  size,
+		order: list.New(),
+		items: map[Key]*list.Element{},
+	}
+}
+
+func (c *LRU) Get(key Key) (Entry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		c.misses++
+		return Entry{}, false
+	}
+
+	c.hits++
+	c.order.MoveToFront(element)
+	return copyEntry(element.Value.(*item).entry), true
+}
+
+func (c *LRU) Add(key Key, entry Entry) {
+	if c.size <= 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if key.Version != c.version {
+		c.purge()
+		c.version = key.Version
+	}
+
+	if element, ok := c.items[key]; ok {
+		element.Value.(*item).entry = copyEntry(entry)
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&item{key: key, entry: copyEntry(entry)})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*item).key)
+	}
+}
+
+// Purge drops every entry, keeping the hit and miss counters.
+func (c *LRU) Purge() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.purge()
+}
+
+func (c *LRU) Stats() Stats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return Stats{
+		Hits:    c.hits,
+		Misses:  c.misses,
+		Entries: c.order.Len(),
+	}
+}
+
+func (c *LRU) purge() {
+	c.order.Init()
+	c.items = map[Key]*list.Element{}
+}
+
+func copyEntry(entry Entry) Entry {
+	result := entry.Result
+	result.Stops = copyStrings(result.Stops)
+	result.PassedThrough = copyStrings(result.PassedThrough)
+	if result.Diagnostics != nil {
+		result.Diagnostics = append([]dto.Diagnostic(nil), result.Diagnostics...)
+	}
+	if result.Path != nil {
+		result.Path = append([]dto.PathStation(nil), result.Path...)
+	}
+	if result.Segments != nil {
+		result.Segments = append([]dto.Segment(nil), result.Segments...)
+		for i := range result.Segments {
+			result.Segments[i].PassedThrough = copyStrings(result.Segments[i].PassedThrough)
+		}
+	}
+
+	entry.Result = result
+	return entry
+}
+
+func copyStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	return append([]string{}, values...)
+}
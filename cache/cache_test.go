@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"buda-challenge/dto"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const (
+	version     = "v1"
+	nextVersion = "v2"
+	stationA    = "A"
+	stationB    = "B"
+	stationC    = "C"
+	trainGreen  = "GREEN"
+)
+
+func Test_WhenKeyWasAdded_ReturnItsEntryAndCountHit(t *testing.T) {
+	lru := NewLRU(2)
+	key := Key{Version: version, InitialStation: stationA, FinalStation: stationC, TrainColor: trainGreen}
+
+	_, found := lru.Get(key)
+	lru.Add(key, Entry{Result: dto.Result{Stops: []string{stationA, stationC}}})
+	entry, foundAfterAdd := lru.Get(key)
+
+	assert.False(t, found)
+	assert.True(t, foundAfterAdd)
+	assert.Equal(t, []string{stationA, stationC}, entry.Result.Stops)
+	assert.Equal(t, Stats{Hits: 1, Misses: 1, Entries: 1}, lru.Stats())
+}
+
+func Test_WhenCacheIsFull_EvictTheLeastRecentlyUsedEntry(t *testing.T) {
+	lru := NewLRU(2)
+	first := Key{Version: version, InitialStation: stationA}
+	second := Key{Version: version, InitialStation: stationB}
+	third := Key{Version: version, InitialStation: stationC}
+
+	lru.Add(first, Entry{})
+	lru.Add(second, Entry{})
+	lru.Get(first)
+	lru.Add(third, Entry{})
+
+	_, firstFound := lru.Get(first)
+	_, secondFound := lru.Get(second)
+	_, thirdFound := lru.Get(third)
+
+	assert.True(t, firstFound)
+	assert.False(t, secondFound)
+	assert.True(t, thirdFound)
+}
+
+func Test_WhenANewNetworkVersionIsAdded_DropEntriesOfThePreviousOne(t *testing.T) {
+	lru := NewLRU(2)
+	old := Key{Version: version, InitialStation: stationA}
+
+	lru.Add(old, Entry{})
+	lru.Add(Key{Version: nextVersion, InitialStation: stationA}, Entry{})
+
+	_, found := lru.Get(old)
+
+	assert.False(t, found)
+	assert.Equal(t, 1, lru.Stats().Entries)
+}
+
+func Test_WhenCachedRouteIsModified_CacheKeepsTheOriginal(t *testing.T) {
+	lru := NewLRU(1)
+	key := Key{Version: version}
+	route := []string{stationA, stationB}
+
+	lru.Add(key, Entry{Result: dto.Result{Stops: route, Segments: []dto.Segment{{From: stationA, To: stationB, PassedThrough: []string{stationC}}}}})
+	route[0] = stationC
+	entry, _ := lru.Get(key)
+	entry.Result.Stops[1] = stationC
+	entry.Result.Segments[0].PassedThrough[0] = stationA
+	again, _ := lru.Get(key)
+
+	assert.Equal(t, []string{stationA, stationB}, again.Result.Stops)
+	assert.Equal(t, []string{stationC}, again.Result.Segments[0].PassedThrough)
+}
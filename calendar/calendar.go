@@ -0,0 +1,180 @@
+// Package calendar tells on which dates the colors, services and service
+// windows of a network run.
+package calendar
+
+import (
+	"buda-challenge/dto"
+	e "buda-challenge/error"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// DateLayout is how dates are written, e.g. 2026-09-18.
+const DateLayout = "2006-01-02"
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// Calendars holds service calendars by ID. It is immutable and safe to share
+// between goroutines.
+type Calendars struct {
+	calendars map[string]calendar
+}
+
+type calendar struct {
+	weekdays map[time.Weekday]bool
+	// start and end are zero when the calendar has no such limit.
+	start   time.Time
+	end     time.Time
+	added   map[string]bool
+	removed map[string]bool
+}
+
+// Load reads and validates the calendar file at path.
+func Load(path string) (*Calendars, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file dto.Calendars
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("%s: %v", e.ErrorInvalidCalendar, err)
+	}
+
+	return New(file)
+}
+
+// New validates file and builds its calendars.
+func New(file dto.Calendars) (*Calendars, error) {
+	calendars := &Calendars{calendars: map[string]calendar{}}
+
+	for _, definition := range file.Calendars {
+		if definition.ID == "" {
+			return nil, fmt.Errorf("%s: calendar without id", e.ErrorInvalidCalendar)
+		}
+		if _, ok := calendars.calendars[definition.ID]; ok {
+			return nil, fmt.Errorf("%s: duplicated calendar %q", e.ErrorInvalidCalendar, definition.ID)
+		}
+
+		parsed, err := parse(definition)
+		if err != nil {
+			return nil, fmt.Errorf("%s: calendar %q: %v", e.ErrorInvalidCalendar, definition.ID, err)
+		}
+		calendars.calendars[definition.ID] = parsed
+	}
+
+	return calendars, nil
+}
+
+func parse(definition dto.Calendar) (calendar, error) {
+	parsed := calendar{
+		weekdays: map[time.Weekday]bool{},
+		added:    map[string]bool{},
+		removed:  map[string]bool{},
+	}
+
+	for _, name := range definition.Weekdays {
+		weekday, ok := weekdays[strings.ToLower(name)]
+		if !ok {
+			return calendar{}, fmt.Errorf("unknown weekday %q", name)
+		}
+		parsed.weekdays[weekday] = true
+	}
+
+	var err error
+	if definition.StartDate != "" {
+		if parsed.start, err = ParseDate(definition.StartDate); err != nil {
+			return calendar{}, err
+		}
+	}
+	if definition.EndDate != "" {
+		if parsed.end, err = ParseDate(definition.EndDate); err != nil {
+			return calendar{}, err
+		}
+	}
+	if !parsed.start.IsZero() && !parsed.end.IsZero() && parsed.end.Before(parsed.start) {
+		return calendar{}, fmt.Errorf("end date %s before start date %s", definition.EndDate, definition.StartDate)
+	}
+
+	for _, dates := range []struct {
+		values []string
+		set    map[string]bool
+	}{{definition.Added, parsed.added}, {definition.Removed, parsed.removed}} {
+		for _, value := range dates.values {
+			date, err := ParseDate(value)
+			if err != nil {
+				return calendar{}, err
+			}
+			dates.set[date.Format(DateLayout)] = true
+		}
+	}
+
+	return parsed, nil
+}
+
+// ParseDate returns the date written as YYYY-MM-DD in value.
+func ParseDate(value string) (time.Time, error) {
+	date, err := time.Parse(DateLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, use YYYY-MM-DD", value)
+	}
+	return date, nil
+}
+
+// Check reports the first of ids that names no calendar. An empty ID names
+// no calendar at all, so it is always valid.
+func (c *Calendars) Check(ids []string) error {
+	for _, id := range ids {
+		if id == "" {
+			continue
+		}
+		if c == nil {
+			return fmt.Errorf("%s: unknown calendar %q, no calendar file loaded", e.ErrorInvalidCalendar, id)
+		}
+		if _, ok := c.calendars[id]; !ok {
+			return fmt.Errorf("%s: unknown calendar %q", e.ErrorInvalidCalendar, id)
+		}
+	}
+	return nil
+}
+
+// Runs reports whether the calendar id runs on date. The empty ID runs every
+// day and an unknown one never does, see Check.
+func (c *Calendars) Runs(id string, date time.Time) bool {
+	if id == "" {
+		return true
+	}
+	if c == nil {
+		return false
+	}
+
+	calendar, ok := c.calendars[id]
+	if !ok {
+		return false
+	}
+
+	day := date.Format(DateLayout)
+	switch {
+	case calendar.added[day]:
+		return true
+	case calendar.removed[day]:
+		return false
+	case !calendar.start.IsZero() && day < calendar.start.Format(DateLayout):
+		return false
+	case !calendar.end.IsZero() && day > calendar.end.Format(DateLayout):
+		return false
+	default:
+		return calendar.weekdays[date.Weekday()]
+	}
+}
@@ -0,0 +1,88 @@
+package calendar
+
+import (
+	"buda-challenge/dto"
+	e "buda-challenge/error"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_GivenACalendar_ReturnWhetherItRunsOnEachDate(t *testing.T) {
+	calendars, err := New(dto.Calendars{Calendars: []dto.Calendar{{
+		ID:        "weekdays",
+		Weekdays:  []string{"monday", "tuesday", "wednesday", "thursday", "Friday"},
+		StartDate: "2026-03-01",
+		EndDate:   "2026-12-31",
+		Added:     []string{"2026-10-17"},
+		Removed:   []string{"2026-09-18"},
+	}}})
+
+	assert.Nil(t, err)
+	assert.True(t, calendars.Runs("weekdays", date(t, "2026-10-19")))
+	assert.True(t, calendars.Runs("weekdays", date(t, "2026-10-23")))
+	assert.False(t, calendars.Runs("weekdays", date(t, "2026-10-18")))
+	assert.True(t, calendars.Runs("weekdays", date(t, "2026-10-17")))
+	assert.False(t, calendars.Runs("weekdays", date(t, "2026-09-18")))
+	assert.False(t, calendars.Runs("weekdays", date(t, "2026-02-27")))
+	assert.False(t, calendars.Runs("weekdays", date(t, "2027-01-04")))
+	assert.True(t, calendars.Runs("", date(t, "2027-01-04")))
+	assert.False(t, calendars.Runs("weekends", date(t, "2026-10-18")))
+}
+
+func Test_GivenCalendarIDs_ReturnErrorForTheUnknownOnes(t *testing.T) {
+	calendars, _ := New(dto.Calendars{Calendars: []dto.Calendar{{ID: "weekdays", Weekdays: []string{"monday"}}}})
+
+	var none *Calendars
+
+	assert.Nil(t, calendars.Check([]string{"weekdays", ""}))
+	assert.NotNil(t, calendars.Check([]string{"weekdays", "weekends"}))
+	assert.Nil(t, none.Check(nil))
+	assert.NotNil(t, none.Check([]string{"weekdays"}))
+	assert.False(t, none.Runs("weekdays", date(t, "2026-10-19")))
+}
+
+func Test_GivenAnInvalidCalendar_ReturnError(t *testing.T) {
+	invalid := [][]dto.Calendar{
+		{{Weekdays: []string{"monday"}}},
+		{{ID: "weekdays"}, {ID: "weekdays"}},
+		{{ID: "weekdays", Weekdays: []string{"mon"}}},
+		{{ID: "weekdays", StartDate: "2026-13-01"}},
+		{{ID: "weekdays", StartDate: "2026-10-01", EndDate: "2026-09-01"}},
+		{{ID: "weekdays", Removed: []string{"18/09/2026"}}},
+	}
+
+	for _, definitions := range invalid {
+		calendars, err := New(dto.Calendars{Calendars: definitions})
+
+		assert.Nil(t, calendars)
+		assert.NotNil(t, err)
+		assert.True(t, strings.HasPrefix(err.Error(), e.ErrorInvalidCalendar))
+	}
+}
+
+func Test_GivenACalendarFile_LoadItsCalendars(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calendar.json")
+	content := `{"calendars": [{"id": "weekends", "weekdays": ["saturday", "sunday"], "added": ["2026-09-18"]}]}`
+	assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+
+	calendars, err := Load(path)
+
+	assert.Nil(t, err)
+	assert.True(t, calendars.Runs("weekends", date(t, "2026-10-18")))
+	assert.True(t, calendars.Runs("weekends", date(t, "2026-09-18")))
+	assert.False(t, calendars.Runs("weekends", date(t, "2026-10-19")))
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.json"))
+
+	assert.NotNil(t, err)
+}
+
+func date(t *testing.T, value string) time.Time {
+	parsed, err := ParseDate(value)
+	assert.Nil(t, err)
+	return parsed
+}
@@ -2,7 +2,13 @@ package configuration
 
 import (
 	"buda-challenge/dto"
+	e "buda-challenge/error"
+	"buda-challenge/i18n"
+	"buda-challenge/network"
 	"buda-challenge/reader"
+	"context"
+	"errors"
+	"time"
 )
 
 const (
@@ -18,43 +24,90 @@ const (
 	TrainRed             = "RED"
 	TrainGreen           = "GREEN"
 	TrainWithoutColour   = "WITHOUT COLOR"
-	trainNetworkFilePath = "configuration/train_network.json"
+	TrainNetworkFilePath = "configuration/train_network.json"
 )
 
 var stations = []string{StationA, StationB, StationC, StationD, StationE, StationF, StationG, StationH, StationI}
 var colors = []string{TrainRed, TrainGreen, TrainWithoutColour}
 
 type Configuration interface {
-	GetConfiguration() (dto.Configuration, error)
-	GetTrainNetwork() ([]dto.Station, error)
-	GetFinalStation() string
+	GetConfiguration(ctx context.Context, snapshot *network.Snapshot) (dto.Configuration, error)
+	GetTrainNetwork(ctx context.Context) ([]dto.Station, error)
+	GetNetwork(ctx context.Context) (*network.Snapshot, error)
 	GetTrainWithoutColor() string
 }
 
 type ConfigurationImpl struct {
 	Reader reader.Reader
+	// Store, when set, serves the network instead of reading the file on
+	// every request.
+	Store *network.Store
+	// Language in which stations and colors are offered. Names in every
+	// other language are accepted too.
+	Language i18n.Language
+	// DepartureTime, as HH:MM, is given to every configuration read. When
+	// empty, every color runs its own stopping pattern.
+	DepartureTime string
+	// Date, as YYYY-MM-DD, is given to every configuration read. When
+	// empty, every color and service runs.
+	Date string
 }
 
-func(c ConfigurationImpl) GetConfiguration() (dto.Configuration, error) {
-	config, err := c.Reader.ReadInput(stations, colors)
+// GetConfiguration reads a configuration offering the stations and colors of
+// snapshot, the network that will answer it, or the default ones when it is
+// nil.
+func(c ConfigurationImpl) GetConfiguration(ctx context.Context, snapshot *network.Snapshot) (dto.Configuration, error) {
+	validStations := make([]dto.Option, len(stations))
+	for i, station := range stations {
+		validStations[i] = dto.Option{Value: station, Label: station}
+	}
+	validColors := make([]dto.Option, len(colors))
+	for i, color := range colors {
+		validColors[i] = dto.Option{Value: color, Label: color}
+	}
+
+	if snapshot != nil {
+		validStations = snapshot.StationOptions(c.Language)
+		validColors = nil
+		for _, color := range snapshot.Colors() {
+			validColors = append(validColors, snapshot.ColorOption(color, c.Language))
+		}
+	}
+
+	config, err := c.Reader.ReadInput(ctx, validStations, validColors)
 	if err != nil {
 		return dto.Configuration{}, err
 	}
+	config.DepartureTime = c.DepartureTime
+	config.Date = c.Date
 
 	return config, nil
 }
 
-func(c ConfigurationImpl) GetTrainNetwork() ([]dto.Station, error) {
-	stations, err := c.Reader.ReadFile(trainNetworkFilePath)
+func(c ConfigurationImpl) GetTrainNetwork(ctx context.Context) ([]dto.Station, error) {
+	snapshot, err := c.GetNetwork(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return stations, nil
+	return snapshot.Stations(), nil
 }
 
-func(c ConfigurationImpl) GetFinalStation() string {
-	return StationF
+func(c ConfigurationImpl) GetNetwork(ctx context.Context) (*network.Snapshot, error) {
+	if c.Store != nil {
+		snapshot := c.Store.Current()
+		if snapshot == nil {
+			return nil, errors.New(e.ErrorReadingFile)
+		}
+		return snapshot, nil
+	}
+
+	trainNetwork, err := c.Reader.ReadFile(ctx, TrainNetworkFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return network.NewSnapshot(trainNetwork, time.Now())
 }
 
 func(c ConfigurationImpl) GetTrainWithoutColor() string {
@@ -2,11 +2,19 @@ package configuration
 
 import (
 	"buda-challenge/dto"
+	"buda-challenge/i18n"
+	"buda-challenge/network"
+	"context"
+	"encoding/json"
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	e "buda-challenge/error"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 const (
@@ -23,7 +31,7 @@ func Test_WhenInputsCanBeReadCorrectly_ReturnsValidConfiguration(t *testing.T) {
 		Reader: mockReader,
 	}
 
-	result, err := config.GetConfiguration()
+	result, err := config.GetConfiguration(context.Background(), nil)
 
 	resultExpected := dto.Configuration{
 		InitialStation: StationA,
@@ -44,7 +52,7 @@ func Test_WhenInputsCanNotBeReadCorrectly_ReturnsError(t *testing.T) {
 		Reader: mockReader,
 	}
 
-	_, err := config.GetConfiguration()
+	_, err := config.GetConfiguration(context.Background(), nil)
 
 	assert.NotNil(t, err)
 	assert.Equal(t, e.ErrorReadingInput, err.Error())
@@ -54,13 +62,13 @@ func Test_WhenFileCanBeReadCorrectly_ReturnsValidTrainNetwork(t *testing.T) {
 	mockReader := new(MockReader)
 
 	mockReader.On(readInputMethodName, mock.Anything, mock.Anything).Return(getConfiguration(StationA, StationF, TrainRed), nil)
-	mockReader.On(readFileMethodName, mock.Anything).Return(getStations())
+	mockReader.On(readFileMethodName, mock.Anything).Return(dto.Network{Stations: getStations()})
 
 	config := ConfigurationImpl{
 		Reader: mockReader,
 	}
 
-	result, err := config.GetTrainNetwork()
+	result, err := config.GetTrainNetwork(context.Background())
 
 	resultExpected := getStations()
 
@@ -78,27 +86,93 @@ func Test_WhenFileCanNotBeReadCorrectly_ReturnsError(t *testing.T) {
 		Reader: mockReader,
 	}
 
-	_, err := config.GetTrainNetwork()
+	_, err := config.GetTrainNetwork(context.Background())
 
 	assert.NotNil(t, err)
 	assert.Equal(t, e.ErrorReadingFile, err.Error())
 }
 
-func Test_ReturnValidTrainWithoutColor(t *testing.T) {
-	result := ConfigurationImpl{}.GetTrainWithoutColor()
+func Test_WhenStoreIsSet_ReturnItsCurrentNetwork(t *testing.T) {
+	dir, err := ioutil.TempDir("", "configuration")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
 
-	assert.Equal(t, TrainWithoutColour, result)
+	path := filepath.Join(dir, "train_network.json")
+	content, _ := json.Marshal(getStations()[:2])
+	assert.Nil(t, ioutil.WriteFile(path, content, 0644))
+
+	mockReader := new(MockReader)
+	mockReader.On(readFileMethodName, mock.Anything).Return(dto.Network{Stations: getStations()[:2]}, nil)
+
+	store := network.NewStore(mockReader, path)
+	assert.Nil(t, store.Reload(context.Background()))
+
+	config := ConfigurationImpl{
+		Reader: mockReader,
+		Store:  store,
+	}
+
+	result, err := config.GetTrainNetwork(context.Background())
+
+	assert.Equal(t, getStations()[:2], result)
+	assert.Nil(t, err)
+	mockReader.AssertNumberOfCalls(t, readFileMethodName, 1)
+}
+
+func Test_WhenSnapshotHasLocalizedNames_OfferThemInTheLanguage(t *testing.T) {
+	stations := getStations()[:2]
+	stations[1].TrainColor = TrainGreen
+	trainNetwork := dto.Network{
+		Stations: stations,
+		Colors:   []dto.Color{{Name: TrainGreen, Names: map[string]string{"es": "VERDE"}}},
+	}
+	snapshot, err := network.NewSnapshot(trainNetwork, time.Now())
+	assert.Nil(t, err)
+
+	mockReader := new(MockReader)
+	mockReader.On(readInputMethodName, mock.Anything, mock.Anything).Return(getConfiguration(StationA, StationB, TrainGreen), nil)
+
+	config := ConfigurationImpl{
+		Reader:   mockReader,
+		Language: i18n.Spanish,
+	}
+
+	_, err = config.GetConfiguration(context.Background(), snapshot)
+
+	assert.Nil(t, err)
+	mockReader.AssertCalled(t, readInputMethodName,
+		[]dto.Option{{Value: StationA, Label: StationA}, {Value: StationB, Label: StationB}},
+		[]dto.Option{{Value: TrainWithoutColour, Label: TrainWithoutColour}, {Value: TrainGreen, Label: "VERDE", Aliases: []string{TrainGreen}}})
 }
 
-func Test_ReturnValidFinalStation(t *testing.T) {
-	result := ConfigurationImpl{}.GetFinalStation()
+func Test_WhenSnapshotHasItsOwnColors_OfferThemInsteadOfTheDefaultOnes(t *testing.T) {
+	stations := getStations()[:2]
+	stations[1].TrainColor = "BLUE"
+	snapshot, err := network.NewSnapshot(dto.Network{Stations: stations}, time.Now())
+	assert.Nil(t, err)
+
+	mockReader := new(MockReader)
+	mockReader.On(readInputMethodName, mock.Anything, mock.Anything).Return(getConfiguration(StationA, StationB, "BLUE"), nil)
 
-	assert.Equal(t, StationF, result)
+	config := ConfigurationImpl{Reader: mockReader}
+
+	_, err = config.GetConfiguration(context.Background(), snapshot)
+
+	assert.Nil(t, err)
+	mockReader.AssertCalled(t, readInputMethodName,
+		[]dto.Option{{Value: StationA, Label: StationA}, {Value: StationB, Label: StationB}},
+		[]dto.Option{{Value: TrainWithoutColour, Label: TrainWithoutColour}, {Value: "BLUE", Label: "BLUE"}})
+}
+
+func Test_ReturnValidTrainWithoutColor(t *testing.T) {
+	result := ConfigurationImpl{}.GetTrainWithoutColor()
+
+	assert.Equal(t, TrainWithoutColour, result)
 }
 
 type MockReader struct { mock.Mock }
 
-func (s *MockReader) ReadInput(stations, colors []string) (dto.Configuration, error) {
+func (s *MockReader) ReadInput(ctx context.Context, stations, colors []dto.Option) (dto.Configuration, error) {
 	args := s.Called(stations, colors)
 
 	if args.Get(0) == nil {
@@ -108,18 +182,18 @@ func (s *MockReader) ReadInput(stations, colors []string) (dto.Configuration, er
 	return args.Get(0).(dto.Configuration), nil
 }
 
-func (s *MockReader) ReadFile(fileName string) ([]dto.Station, error) {
+func (s *MockReader) ReadFile(ctx context.Context, fileName string) (dto.Network, error) {
 	args := s.Called(fileName)
 
 	if args.Get(0) == nil {
-		return []dto.Station{}, args.Error(1)
+		return dto.Network{}, args.Error(1)
 	}
 
-	return args.Get(0).([]dto.Station), nil
+	return args.Get(0).(dto.Network), nil
 }
 
-func (s *MockReader) Read(requiredValue string, validValues []string) (string, error) {
-	args := s.Called(requiredValue, validValues)
+func (s *MockReader) Read(ctx context.Context, requiredValue string, options []dto.Option) (string, error) {
+	args := s.Called(requiredValue, options)
 
 	if args.Get(0) == nil {
 		return "", args.Error(1)
@@ -0,0 +1,151 @@
+// Package diagram lays out a train network as a line diagram on a grid of
+// characters. Every fork is drawn as a branch below the line it leaves.
+package diagram
+
+import (
+	"buda-challenge/dto"
+	"buda-challenge/network"
+	"unicode/utf8"
+)
+
+// Node is a station placed on the diagram. Slot is its horizontal position
+// and Row the line, or branch, it is drawn on.
+type Node struct {
+	Name string
+	Slot int
+	Row  int
+}
+
+// Edge joins two stations next to each other on the network.
+type Edge struct {
+	From string
+	To   string
+}
+
+// Diagram is the layout of a network. Build it with New.
+type Diagram struct {
+	nodes []Node
+	index map[string]int
+	edges []Edge
+	slots int
+	rows  int
+	width int
+	// loop is true when the last station connects back to the first.
+	loop bool
+}
+
+// gap is the number of characters between two stations on a line.
+const gap = 3
+
+// New lays out stations: the stations of a line go left to right on the
+// same row, the first fork of a station continues its row and every other
+// fork gets rows of its own below. The station after the forks joins them.
+func New(stations []dto.Station) *Diagram {
+	d := &Diagram{index: map[string]int{}}
+	d.slots, d.rows, _ = d.place(stations, 0, 0, nil)
+
+	for _, node := range d.nodes {
+		if width := utf8.RuneCountInString(node.Name) + 2; width > d.width {
+			d.width = width
+		}
+	}
+
+	return d
+}
+
+// NewCircle lays out stations as New does and joins the last of them back
+// to the first, as on a circle line.
+func NewCircle(stations []dto.Station) *Diagram {
+	d := New(stations)
+	if len(stations) > 1 {
+		d.edges = append(d.edges, Edge{From: stations[len(stations)-1].Name, To: stations[0].Name})
+		d.loop = true
+	}
+	return d
+}
+
+// Of lays out the network of snapshot, closing it when it is a circle line.
+func Of(snapshot *network.Snapshot) *Diagram {
+	if snapshot.Circular() {
+		return NewCircle(snapshot.Stations())
+	}
+	return New(snapshot.Stations())
+}
+
+// place lays out stations from slot on row, joining the first of them to
+// every station in from. It returns the slot after the last station, the
+// rows used and the stations the next one must join.
+func (d *Diagram) place(stations []dto.Station, slot, row int, from []string) (int, int, []string) {
+	rows := 1
+	for _, station := range stations {
+		d.index[station.Name] = len(d.nodes)
+		d.nodes = append(d.nodes, Node{Name: station.Name, Slot: slot, Row: row})
+		for _, previous := range from {
+			d.edges = append(d.edges, Edge{From: previous, To: station.Name})
+		}
+		from = []string{station.Name}
+		slot++
+
+		if len(station.Forks) == 0 {
+			continue
+		}
+
+		end, branchRow := slot, row
+		var tails []string
+		for _, fork := range station.Forks {
+			forkEnd, forkRows, forkTails := d.place(fork, slot, branchRow, []string{station.Name})
+			if forkEnd > end {
+				end = forkEnd
+			}
+			branchRow += forkRows
+			tails = append(tails, forkTails...)
+		}
+		if branchRow-row > rows {
+			rows = branchRow - row
+		}
+		slot, from = end, tails
+	}
+
+	return slot, rows, from
+}
+
+// Nodes returns the stations in the order of the network file.
+func (d *Diagram) Nodes() []Node {
+	return append([]Node(nil), d.nodes...)
+}
+
+// Edges returns every pair of stations next to each other.
+func (d *Diagram) Edges() []Edge {
+	return append([]Edge(nil), d.edges...)
+}
+
+// Node returns the station called name.
+func (d *Diagram) Node(name string) (Node, bool) {
+	i, ok := d.index[name]
+	if !ok {
+		return Node{}, false
+	}
+	return d.nodes[i], true
+}
+
+// Column returns the first column of the label of node.
+func (d *Diagram) Column(node Node) int {
+	return node.Slot * (d.width + gap)
+}
+
+// Line returns the line of the grid node is drawn on.
+func (d *Diagram) Line(node Node) int {
+	return node.Row * 2
+}
+
+// Size returns the columns and lines of the grid.
+func (d *Diagram) Size() (int, int) {
+	if d.slots == 0 {
+		return 0, 0
+	}
+	lines := d.rows*2 - 1
+	if d.loop {
+		lines += 2
+	}
+	return d.slots*(d.width+gap) - gap, lines
+}
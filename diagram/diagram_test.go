@@ -0,0 +1,66 @@
+package diagram
+
+import (
+	"buda-challenge/dto"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func Test_GivenAForkedNetwork_PlaceTheSecondForkOnABranchBelow(t *testing.T) {
+	d := New(getStations())
+
+	node, ok := d.Node("G")
+	assert.True(t, ok)
+	assert.Equal(t, Node{Name: "G", Slot: 3, Row: 1}, node)
+	node, _ = d.Node("F")
+	assert.Equal(t, Node{Name: "F", Slot: 6, Row: 0}, node)
+	assert.Contains(t, d.Edges(), Edge{From: "C", To: "G"})
+	assert.Contains(t, d.Edges(), Edge{From: "E", To: "F"})
+	assert.Contains(t, d.Edges(), Edge{From: "I", To: "F"})
+	assert.Len(t, d.Edges(), 9)
+}
+
+func Test_GivenARoute_MarkItsStationsAndConnections(t *testing.T) {
+	d := New(getStations())
+
+	grid := d.Cells(Marks{
+		Stops: map[string]bool{"A": true, "B": true, "C": true, "G": true, "I": true, "F": true},
+		Route: []string{"C", "G", "H", "I"},
+	})
+
+	assert.Equal(t, []string{
+		"[A]---[B]---[C]---(D)---(E)---------[F]",
+		"             |                       | ",
+		"             +----[G]---(H)---[I]----+ ",
+	}, text(grid))
+	assert.True(t, grid[1][13].Route)
+	assert.True(t, grid[2][19].Route)
+	assert.Equal(t, "H", grid[2][25].Station)
+	assert.False(t, grid[0][18].Route)
+	assert.False(t, grid[2][35].Route)
+}
+
+func getStations() []dto.Station {
+	return []dto.Station{
+		{Name: "A"},
+		{Name: "B"},
+		{Name: "C", Forks: [][]dto.Station{
+			{{Name: "D"}, {Name: "E"}},
+			{{Name: "G"}, {Name: "H"}, {Name: "I"}},
+		}},
+		{Name: "F"},
+	}
+}
+
+func text(grid [][]Cell) []string {
+	lines := make([]string, len(grid))
+	for i, row := range grid {
+		var line strings.Builder
+		for _, cell := range row {
+			line.WriteRune(cell.Rune)
+		}
+		lines[i] = line.String()
+	}
+	return lines
+}
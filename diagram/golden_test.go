@@ -0,0 +1,87 @@
+package diagram
+
+import (
+	"buda-challenge/dto"
+	"bytes"
+	"flag"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+func Test_GivenTheExampleNetworkAndAGreenRoute_MatchTheGoldenDiagram(t *testing.T) {
+	assertGolden(t, "example_green.txt", New(getStations()), Marks{
+		Stops: map[string]bool{"A": true, "B": true, "C": true, "D": true, "E": true, "G": true, "I": true, "F": true},
+		Route: []string{"A", "B", "C", "G", "H", "I", "F"},
+	})
+}
+
+func Test_GivenTheExampleNetworkWithoutRoute_MatchTheGoldenDiagram(t *testing.T) {
+	assertGolden(t, "example_red.txt", New(getStations()), Marks{
+		Stops: map[string]bool{"A": true, "B": true, "C": true, "D": true, "E": true, "H": true, "F": true},
+	})
+}
+
+func Test_GivenALineWithRealNames_MatchTheGoldenDiagram(t *testing.T) {
+	assertGolden(t, "real_names.txt", New([]dto.Station{
+		{Name: "Tobalaba"},
+		{Name: "Los Leones"},
+		{Name: "Pedro de Valdivia"},
+		{Name: "Salvador"},
+		{Name: "Baquedano"},
+	}), Marks{
+		Stops: map[string]bool{"Tobalaba": true, "Los Leones": true, "Salvador": true, "Baquedano": true},
+		Route: []string{"Baquedano", "Salvador", "Pedro de Valdivia", "Los Leones"},
+	})
+}
+
+func Test_GivenThreeForksAndANestedOne_MatchTheGoldenDiagram(t *testing.T) {
+	assertGolden(t, "nested_forks.txt", New([]dto.Station{
+		{Name: "A"},
+		{Name: "B", Forks: [][]dto.Station{
+			{{Name: "C"}},
+			{{Name: "D", Forks: [][]dto.Station{
+				{{Name: "E"}, {Name: "F"}},
+				{{Name: "G"}},
+			}}, {Name: "H"}},
+			{{Name: "I"}, {Name: "J"}},
+		}},
+		{Name: "K"},
+	}), Marks{Route: []string{"A", "B", "D", "G", "H", "K"}})
+}
+
+func Test_GivenBranchesEndingAtTerminals_MatchTheGoldenDiagram(t *testing.T) {
+	assertGolden(t, "terminals.txt", New([]dto.Station{
+		{Name: "A"},
+		{Name: "B", Forks: [][]dto.Station{
+			{{Name: "C"}, {Name: "D"}},
+			{{Name: "E"}},
+		}},
+	}), Marks{Route: []string{"D", "C", "B", "E"}})
+}
+
+func Test_GivenACircleLine_MatchTheGoldenDiagram(t *testing.T) {
+	assertGolden(t, "circle.txt", NewCircle([]dto.Station{
+		{Name: "A"},
+		{Name: "B"},
+		{Name: "C"},
+		{Name: "D"},
+	}), Marks{Route: []string{"C", "D", "A"}})
+}
+
+func assertGolden(t *testing.T, name string, d *Diagram, marks Marks) {
+	var output bytes.Buffer
+	assert.Nil(t, d.Write(&output, marks))
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		assert.Nil(t, ioutil.WriteFile(path, output.Bytes(), 0644))
+	}
+
+	golden, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, string(golden), output.String())
+}
@@ -0,0 +1,126 @@
+package diagram
+
+import (
+	"unicode/utf8"
+)
+
+// Marks decide how stations and connections are drawn.
+type Marks struct {
+	// Stops holds the stations where the chosen train stops. Their labels
+	// are drawn in brackets and every other one in parentheses. When Stops
+	// is nil, every station is a stop.
+	Stops map[string]bool
+	// Route is the physical path to highlight, in travel order.
+	Route []string
+}
+
+// Cell is a character of the grid.
+type Cell struct {
+	Rune rune
+	// Station is the station whose label the cell belongs to, if any.
+	Station string
+	// Route is true when the cell draws a station or a connection of the
+	// highlighted route.
+	Route bool
+}
+
+// Cells draws the diagram with marks.
+func (d *Diagram) Cells(marks Marks) [][]Cell {
+	columns, lines := d.Size()
+	grid := make([][]Cell, lines)
+	for i := range grid {
+		grid[i] = make([]Cell, columns)
+		for j := range grid[i] {
+			grid[i][j].Rune = ' '
+		}
+	}
+
+	onRoute := map[Edge]bool{}
+	routeStations := map[string]bool{}
+	for i, name := range marks.Route {
+		routeStations[name] = true
+		if i > 0 {
+			onRoute[Edge{From: marks.Route[i-1], To: name}] = true
+			onRoute[Edge{From: name, To: marks.Route[i-1]}] = true
+		}
+	}
+
+	for _, edge := range d.edges {
+		d.drawEdge(grid, edge, onRoute[edge])
+	}
+
+	for _, node := range d.nodes {
+		open, close := '[', ']'
+		if marks.Stops != nil && !marks.Stops[node.Name] {
+			open, close = '(', ')'
+		}
+
+		label := []rune(string(open) + node.Name + string(close))
+		line, column := d.Line(node), d.Column(node)
+		for i, r := range label {
+			grid[line][column+i] = Cell{Rune: r, Station: node.Name, Route: routeStations[node.Name]}
+		}
+	}
+
+	return grid
+}
+
+// drawEdge connects two stations: straight when they share a row, down
+// and right for a fork, right and up for a join. The edge closing a circle
+// line runs back left under the row.
+func (d *Diagram) drawEdge(grid [][]Cell, edge Edge, route bool) {
+	from, to := d.nodes[d.index[edge.From]], d.nodes[d.index[edge.To]]
+	fromLine, toLine := d.Line(from), d.Line(to)
+	start := d.Column(from) + d.labelWidth(from)
+
+	switch {
+	case fromLine == toLine && to.Slot < from.Slot:
+		right := d.Column(from) + d.labelWidth(from)/2
+		left := d.Column(to) + d.labelWidth(to)/2
+		d.set(grid, fromLine+1, right, '|', route)
+		d.set(grid, fromLine+2, right, '+', route)
+		d.horizontal(grid, fromLine+2, left+1, right-1, route)
+		d.set(grid, fromLine+2, left, '+', route)
+		d.set(grid, fromLine+1, left, '|', route)
+	case fromLine == toLine:
+		d.horizontal(grid, fromLine, start, d.Column(to)-1, route)
+	case fromLine < toLine:
+		x := d.Column(from) + d.labelWidth(from)/2
+		d.vertical(grid, x, fromLine+1, toLine-1, route)
+		d.set(grid, toLine, x, '+', route)
+		d.horizontal(grid, toLine, x+1, d.Column(to)-1, route)
+	default:
+		x := d.Column(to) + d.labelWidth(to)/2
+		d.horizontal(grid, fromLine, start, x-1, route)
+		d.set(grid, fromLine, x, '+', route)
+		d.vertical(grid, x, toLine+1, fromLine-1, route)
+	}
+}
+
+func (d *Diagram) horizontal(grid [][]Cell, line, from, to int, route bool) {
+	for column := from; column <= to; column++ {
+		d.set(grid, line, column, '-', route)
+	}
+}
+
+func (d *Diagram) vertical(grid [][]Cell, column, from, to int, route bool) {
+	for line := from; line <= to; line++ {
+		d.set(grid, line, column, '|', route)
+	}
+}
+
+// set draws r, turning crossings into '+'.
+func (d *Diagram) set(grid [][]Cell, line, column int, r rune, route bool) {
+	cell := &grid[line][column]
+	switch {
+	case cell.Rune == ' ' || cell.Rune == r:
+		cell.Rune = r
+	default:
+		cell.Rune = '+'
+	}
+	cell.Route = cell.Route || route
+}
+
+func (d *Diagram) labelWidth(node Node) int {
+	return utf8.RuneCountInString(node.Name) + 2
+}
@@ -0,0 +1,37 @@
+package diagram
+
+import (
+	"io"
+	"strings"
+)
+
+// Write draws the diagram with marks in plain ASCII. Connections of the
+// route are drawn with '=' and '#' instead of '-', '|' and '+'. Trailing
+// spaces are trimmed so the output is stable.
+func (d *Diagram) Write(w io.Writer, marks Marks) error {
+	for _, row := range d.Cells(marks) {
+		var line strings.Builder
+		for _, cell := range row {
+			line.WriteRune(routeRune(cell))
+		}
+		if _, err := io.WriteString(w, strings.TrimRight(line.String(), " ")+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func routeRune(cell Cell) rune {
+	if !cell.Route || cell.Station != "" {
+		return cell.Rune
+	}
+
+	switch cell.Rune {
+	case '-':
+		return '='
+	case '|', '+':
+		return '#'
+	default:
+		return cell.Rune
+	}
+}
@@ -0,0 +1,20 @@
+package dto
+
+// Calendars is the content of a service calendar file.
+type Calendars struct {
+	Calendars []Calendar `json:"calendars"`
+}
+
+// Calendar tells on which dates the colors, services and service windows
+// naming its ID run: on the Weekdays between StartDate and EndDate, both
+// included and optional, plus the Added dates and minus the Removed ones.
+// Dates are written as YYYY-MM-DD and weekdays by their English name, e.g.
+// "monday".
+type Calendar struct {
+	ID        string   `json:"id"`
+	Weekdays  []string `json:"weekdays"`
+	StartDate string   `json:"start_date,omitempty"`
+	EndDate   string   `json:"end_date,omitempty"`
+	Added     []string `json:"added,omitempty"`
+	Removed   []string `json:"removed,omitempty"`
+}
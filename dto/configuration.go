@@ -4,4 +4,10 @@ type Configuration struct {
 	InitialStation string `json:"initial_station"`
 	FinalStation string `json:"final_station"`
 	TrainColor string `json:"train_color"`
+	// DepartureTime is when the train leaves, as HH:MM. When empty, every
+	// color runs its own stopping pattern.
+	DepartureTime string `json:"departure_time,omitempty"`
+	// Date is the day of travel, as YYYY-MM-DD. When empty, every color
+	// and service runs.
+	Date string `json:"date,omitempty"`
 }
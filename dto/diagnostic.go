@@ -0,0 +1,46 @@
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Diagnostic is a note about a result. Message is written in English with
+// a verb for every argument, so that it can be translated before Args fill
+// it in.
+type Diagnostic struct {
+	Message string
+	Args    []string
+}
+
+func NewDiagnostic(message string, args ...string) Diagnostic {
+	return Diagnostic{Message: message, Args: args}
+}
+
+// Arguments returns Args ready to format a translation of Message with.
+func (d Diagnostic) Arguments() []interface{} {
+	args := make([]interface{}, len(d.Args))
+	for i, arg := range d.Args {
+		args[i] = arg
+	}
+	return args
+}
+
+// String returns the diagnostic in English.
+func (d Diagnostic) String() string {
+	if len(d.Args) == 0 {
+		return d.Message
+	}
+	return fmt.Sprintf(d.Message, d.Arguments()...)
+}
+
+// MarshalJSON writes the diagnostic as its English text.
+func (d Diagnostic) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON reads the text written by MarshalJSON.
+func (d *Diagnostic) UnmarshalJSON(data []byte) error {
+	d.Args = nil
+	return json.Unmarshal(data, &d.Message)
+}
@@ -0,0 +1,67 @@
+package dto
+
+// Network is the content of a train network file.
+type Network struct {
+	Stations []Station `json:"stations"`
+	Colors   []Color   `json:"colors,omitempty"`
+	// Circular is true when the last station connects back to the first.
+	Circular bool `json:"circular,omitempty"`
+	// Services replace the stops given by the stations for their colors.
+	Services []Service `json:"services,omitempty"`
+	// Timing is how long trains take between stations. When nil, the
+	// defaults of the network package apply.
+	Timing *Timing `json:"timing,omitempty"`
+}
+
+// Timing gives the time, in minutes, a train takes to run from a station to
+// the next one and the time it loses at every stop along the way.
+type Timing struct {
+	RunMinutes  float64 `json:"run_minutes"`
+	StopMinutes float64 `json:"stop_minutes"`
+}
+
+// Color describes a train color used by the stations of the network.
+type Color struct {
+	Name string `json:"name"`
+	// Names holds the display name of the color by language, e.g. "es".
+	Names map[string]string `json:"names,omitempty"`
+	// Windows are the times of day the color runs its own stopping pattern.
+	// Outside them its trains stop at every station. A color without
+	// windows always runs its pattern.
+	Windows []Window `json:"windows,omitempty"`
+	// Calendar, when set, is the ID of the calendar of the days the color
+	// runs. Otherwise it runs every day.
+	Calendar string `json:"calendar,omitempty"`
+	// Headways are how often the trains of the color leave, by time of day.
+	Headways []Headway `json:"headways,omitempty"`
+}
+
+// Headway is the time, in minutes, between two trains leaving within the
+// window from From to To, written and applied as those of a Window. A
+// headway without From and To applies all day.
+type Headway struct {
+	From     string  `json:"from,omitempty"`
+	To       string  `json:"to,omitempty"`
+	Minutes  float64 `json:"minutes"`
+	Calendar string  `json:"calendar,omitempty"`
+}
+
+// Window is a time of day between From, included, and To, excluded, both
+// written as HH:MM. A window whose end comes before its start runs past
+// midnight.
+type Window struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Calendar, when set, limits the window to the days of that calendar.
+	Calendar string `json:"calendar,omitempty"`
+}
+
+// Service is a train of a color that stops at Stops, in that order, and turns
+// back at both ends, e.g. a short turn that runs over part of a line only.
+// A color may run several services.
+type Service struct {
+	Color string   `json:"color"`
+	Stops []string `json:"stops"`
+	// Calendar, when set, limits the service to the days of that calendar.
+	Calendar string `json:"calendar,omitempty"`
+}
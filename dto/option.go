@@ -0,0 +1,9 @@
+package dto
+
+// Option is a value the user can choose. It is shown as Label and also
+// accepted under any of its Aliases.
+type Option struct {
+	Value   string   `json:"value"`
+	Label   string   `json:"label"`
+	Aliases []string `json:"aliases,omitempty"`
+}
@@ -0,0 +1,8 @@
+package dto
+
+// PathStation is a station the train physically runs through. Stop is false
+// when the train passes through it without stopping.
+type PathStation struct {
+	Name string `json:"name"`
+	Stop bool   `json:"stop"`
+}
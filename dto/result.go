@@ -0,0 +1,39 @@
+package dto
+
+type Result struct {
+	Stops          []string      `json:"stops"`
+	PassedThrough  []string      `json:"passed_through"`
+	Path           []PathStation `json:"path"`
+	Segments       []Segment     `json:"segments"`
+	Totals         Totals        `json:"totals"`
+	TrainColor     string        `json:"train_color"`
+	ServicePattern string        `json:"service_pattern"`
+	Towards        string        `json:"towards"`
+	NetworkVersion string        `json:"network_version"`
+	Diagnostics    []Diagnostic  `json:"diagnostics"`
+	Expected       *Expectation  `json:"expected,omitempty"`
+	Local          *Expectation  `json:"local,omitempty"`
+}
+
+// Expectation is the expected time, in minutes, of a journey by a train of
+// TrainColor: half its headway waiting for it and then the ride.
+type Expectation struct {
+	TrainColor   string  `json:"train_color"`
+	WaitMinutes  float64 `json:"wait_minutes"`
+	RideMinutes  float64 `json:"ride_minutes"`
+	TotalMinutes float64 `json:"total_minutes"`
+}
+
+type Segment struct {
+	From          string   `json:"from"`
+	To            string   `json:"to"`
+	PassedThrough []string `json:"passed_through"`
+	Distance      int      `json:"distance"`
+}
+
+type Totals struct {
+	Stops         int `json:"stops"`
+	PassedThrough int `json:"passed_through"`
+	Segments      int `json:"segments"`
+	Distance      int `json:"distance"`
+}
@@ -1,7 +1,32 @@
 package dto
 
+// Directions of travel along a line: forward follows the order of the network
+// file and backward the opposite one.
+const (
+	DirectionForward  = "forward"
+	DirectionBackward = "backward"
+)
+
 type Station struct {
 	Name  string `json:"name"`
 	Forks [][]Station `json:"forks"`
 	TrainColor string `json:"train_color"`
+	// TrainColors overrides TrainColor by direction of travel.
+	TrainColors map[string]string `json:"train_colors,omitempty"`
+	// Names holds the display name of the station by language, e.g. "es".
+	Names map[string]string `json:"names,omitempty"`
+	// Code and Aliases are other names the station is known by.
+	Code string `json:"code,omitempty"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// Walk calls visit with every station of stations in file order, each one
+// followed by the stations of its forks, nested forks included.
+func Walk(stations []Station, visit func(station Station)) {
+	for _, station := range stations {
+		visit(station)
+		for _, fork := range station.Forks {
+			Walk(fork, visit)
+		}
+	}
 }
@@ -4,4 +4,9 @@ const (
 	ErrorReadingInput = "error reading input"
 	ErrorReadingFile = "error reading file"
 	ErrorInvalidCombination = "invalid combination"
+	ErrorInvalidNetwork = "invalid network"
+	ErrorInvalidCalendar = "invalid calendar"
+	ErrorNotRunning = "train color does not run on that date"
+	ErrorTimeout = "request timed out"
+	ErrorCanceled = "request canceled"
 )
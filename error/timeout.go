@@ -0,0 +1,30 @@
+package error
+
+import (
+	"context"
+)
+
+// TimeoutError is returned when a request is aborted because its context
+// expired or was canceled. Cause holds the underlying context error.
+type TimeoutError struct {
+	Cause error
+}
+
+func (t TimeoutError) Error() string {
+	if t.Cause == context.Canceled {
+		return ErrorCanceled
+	}
+	return ErrorTimeout
+}
+
+func (t TimeoutError) Unwrap() error {
+	return t.Cause
+}
+
+// FromContext returns a TimeoutError when ctx is done and nil otherwise.
+func FromContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return TimeoutError{Cause: err}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package error
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_WhenContextIsActive_ReturnNil(t *testing.T) {
+	assert.Nil(t, FromContext(context.Background()))
+}
+
+func Test_WhenContextIsCanceled_ReturnCanceledError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := FromContext(ctx)
+
+	var timeout TimeoutError
+	assert.True(t, errors.As(err, &timeout))
+	assert.Equal(t, ErrorCanceled, err.Error())
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func Test_WhenContextDeadlineExpires_ReturnTimeoutError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	err := FromContext(ctx)
+
+	assert.Equal(t, ErrorTimeout, err.Error())
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
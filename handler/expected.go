@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"buda-challenge/dto"
+	e "buda-challenge/error"
+	"buda-challenge/network"
+	"buda-challenge/processor"
+	"context"
+	"errors"
+	"strconv"
+)
+
+// expect sets the expected time of result, the answer to config, from the
+// headway of its color at the departure time, and compares it with taking
+// the next train without color instead unless pattern, the stops the trains
+// of the color make at that time, already are those of a train without
+// color. Results stay without expected time when there is no departure time
+// or no headway applies.
+func (handler Handler) expect(ctx context.Context, snapshot *network.Snapshot, config dto.Configuration, pattern string, result *dto.Result) error {
+	if config.DepartureTime == "" {
+		return nil
+	}
+	departure, err := network.ParseTimeOfDay(config.DepartureTime)
+	if err != nil {
+		return errors.New(e.ErrorReadingInput)
+	}
+	applies, err := handler.applies(snapshot, config)
+	if err != nil {
+		return err
+	}
+
+	headway, ok := snapshot.Headway(config.TrainColor, departure, applies)
+	if !ok {
+		return nil
+	}
+	expected, err := handler.Processor.GetExpectation(ctx, config.TrainColor, headway, snapshot.Timing(), *result)
+	if err != nil {
+		return err
+	}
+	result.Expected = &expected
+
+	withoutColor := handler.Configuration.GetTrainWithoutColor()
+	if config.TrainColor == withoutColor || pattern == withoutColor {
+		return nil
+	}
+	localHeadway, ok := snapshot.Headway(withoutColor, departure, applies)
+	if !ok {
+		return nil
+	}
+
+	local, err := handler.resolve(ctx, snapshot, leg(config, config.InitialStation, config.FinalStation, withoutColor), &trace{})
+	if err != nil {
+		var timeout e.TimeoutError
+		if errors.As(err, &timeout) {
+			return err
+		}
+		return nil
+	}
+
+	localExpected, err := handler.Processor.GetExpectation(ctx, withoutColor, localHeadway, snapshot.Timing(), local)
+	if err != nil {
+		return err
+	}
+	result.Local = &localExpected
+	if processor.IsFasterExpectation(expected, localExpected) {
+		result.Diagnostics = append(result.Diagnostics, dto.NewDiagnostic("Taking the next %s train is expected to be faster: %s minutes instead of %s", withoutColor, minutes(localExpected.TotalMinutes), minutes(expected.TotalMinutes)))
+	}
+	return nil
+}
+
+func minutes(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -1,40 +1,451 @@
 package handler
 
 import (
+	"buda-challenge/cache"
+	"buda-challenge/calendar"
 	"buda-challenge/configuration"
+	"buda-challenge/dto"
+	"buda-challenge/logger"
+	"buda-challenge/metrics"
+	"buda-challenge/network"
 	"buda-challenge/processor"
+	"buda-challenge/routetable"
 	e "buda-challenge/error"
+	"context"
 	"errors"
+	"time"
 )
 
 type Handler struct {
 	Configuration configuration.Configuration
 	Processor processor.Processor
+	// Timeout bounds the time spent loading the network and searching for
+	// the route once the input has been read. Zero means no limit.
+	Timeout time.Duration
+	// Table, when set, answers queries from precomputed routes while it
+	// matches the network being served.
+	Table *routetable.Precomputed
+	// Cache, when set, keeps the latest answers keyed by network version.
+	Cache *cache.LRU
+	// Metrics, when set, records request outcomes and stage latencies.
+	Metrics *metrics.Planner
+	// Logger, when set, logs every query with its request ID.
+	Logger *logger.Logger
+	// Calendars tell on which dates the calendars named by the network
+	// run. Queries with a date need them when the network names any.
+	Calendars *calendar.Calendars
 }
 
-func (handler Handler) HandleRequest() ([]string, error) {
-	config, err := handler.Configuration.GetConfiguration()
+// trace collects what a query used, to be logged once it finishes.
+type trace struct {
+	snapshot *network.Snapshot
+	version  string
+	source   string
+}
+
+const (
+	sourceTable     = "table"
+	sourceCache     = "cache"
+	sourceProcessor = "processor"
+)
+
+func (handler Handler) HandleRequest(ctx context.Context) (dto.Result, error) {
+	ctx = logger.EnsureRequestID(ctx)
+
+	snapshot := handler.current(ctx)
+	config, err := handler.ReadConfiguration(ctx, snapshot)
 	if err != nil {
-		return nil, errors.New(e.ErrorReadingInput)
+		return dto.Result{}, err
 	}
 
-	stations, err := handler.Configuration.GetTrainNetwork()
+	return handler.HandleSnapshotQuery(ctx, snapshot, config)
+}
+
+// HandleJourneyRequest reads the input and plans the journey, see PlanJourney.
+func (handler Handler) HandleJourneyRequest(ctx context.Context) ([]dto.Result, error) {
+	ctx = logger.EnsureRequestID(ctx)
+
+	snapshot := handler.current(ctx)
+	config, err := handler.ReadConfiguration(ctx, snapshot)
 	if err != nil {
-		return nil, errors.New(e.ErrorReadingFile)
+		return nil, err
 	}
 
-	stationsWithoutForks, forks := handler.Processor.GetStations(stations, config.TrainColor)
+	return handler.PlanSnapshotJourney(ctx, snapshot, config)
+}
 
-	routes := handler.Processor.GetRoutes(stationsWithoutForks, forks)
+// current returns the network the next query is read against and answered
+// on, or nil when it can not be loaded; the query then loads it again and
+// reports why it failed.
+func (handler Handler) current(ctx context.Context) *network.Snapshot {
+	if e.FromContext(ctx) != nil {
+		return nil
+	}
+
+	snapshot, err := handler.Configuration.GetNetwork(ctx)
+	if err != nil {
+		return nil
+	}
+	return snapshot
+}
+
+// ReadConfiguration reads the initial station, final station and train color
+// among those of snapshot.
+func (handler Handler) ReadConfiguration(ctx context.Context, snapshot *network.Snapshot) (dto.Configuration, error) {
+	config, err := handler.Configuration.GetConfiguration(ctx, snapshot)
+	if err != nil {
+		handler.Logger.Warn(ctx, "unable to read input", logger.Fields{"error": err})
+		if timeoutErr := e.FromContext(ctx); timeoutErr != nil {
+			err = timeoutErr
+		} else {
+			err = errors.New(e.ErrorReadingInput)
+		}
+		handler.Metrics.ObserveRequest(outcome(err))
+		return dto.Configuration{}, err
+	}
 
-	handler.Processor.SortRoutes(routes, handler.Configuration.GetFinalStation())
+	return config, nil
+}
+
+// HandleQuery finds the route for an already read configuration on the
+// network being served.
+func (handler Handler) HandleQuery(ctx context.Context, config dto.Configuration) (dto.Result, error) {
+	return handler.HandleSnapshotQuery(ctx, nil, config)
+}
+
+// HandleSnapshotQuery finds the route for config on snapshot, the network it
+// was read against. A nil snapshot stands for the network being served.
+func (handler Handler) HandleSnapshotQuery(ctx context.Context, snapshot *network.Snapshot, config dto.Configuration) (dto.Result, error) {
+	var queryTrace trace
+	return handler.query(ctx, snapshot, config, &queryTrace)
+}
+
+func (handler Handler) query(ctx context.Context, snapshot *network.Snapshot, config dto.Configuration, queryTrace *trace) (dto.Result, error) {
+	ctx = logger.EnsureRequestID(ctx)
+	start := time.Now()
+
+	result, err := handler.handleQuery(ctx, snapshot, config, queryTrace)
+	handler.Metrics.ObserveRequest(outcome(err))
+
+	fields := logger.Fields{
+		"initial_station": config.InitialStation,
+		"final_station":   config.FinalStation,
+		"train_color":     config.TrainColor,
+		"network_version": queryTrace.version,
+		"source":          queryTrace.source,
+		"route":           result.Stops,
+		"duration_ms":     logger.Milliseconds(start),
+		"outcome":         outcome(err),
+	}
+	if err != nil {
+		fields["error"] = err
+	}
+	if err != nil && outcome(err) != metrics.OutcomeInvalidCombination {
+		handler.Logger.Warn(ctx, "query failed", fields)
+	} else {
+		handler.Logger.Info(ctx, "query answered", fields)
+	}
+
+	return result, err
+}
+
+func (handler Handler) handleQuery(ctx context.Context, snapshot *network.Snapshot, config dto.Configuration, queryTrace *trace) (dto.Result, error) {
+	if handler.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, handler.Timeout)
+		defer cancel()
+	}
 
-	shortestRoute := handler.Processor.GetShortestRoute(routes, config.InitialStation, config.FinalStation)
+	if err := e.FromContext(ctx); err != nil {
+		return dto.Result{}, err
+	}
+
+	if snapshot == nil {
+		var err error
+		snapshot, err = handler.Configuration.GetNetwork(ctx)
+		if err != nil {
+			if timeoutErr := e.FromContext(ctx); timeoutErr != nil {
+				return dto.Result{}, timeoutErr
+			}
+			return dto.Result{}, errors.New(e.ErrorReadingFile)
+		}
+	}
+	queryTrace.snapshot, queryTrace.version = snapshot, snapshot.Version()
+
+	if !snapshot.HasStation(config.InitialStation) || !snapshot.HasStation(config.FinalStation) {
+		return dto.Result{}, errors.New(e.ErrorReadingInput)
+	}
+
+	trainSchedule, err := handler.schedule(snapshot, config)
+	if err != nil {
+		return dto.Result{}, err
+	}
+	result, err := handler.resolveScheduled(ctx, snapshot, config, trainSchedule, queryTrace)
+	if err != nil {
+		return dto.Result{}, err
+	}
+
+	if err := handler.expect(ctx, snapshot, config, trainSchedule.pattern, &result); err != nil {
+		return dto.Result{}, err
+	}
+	return result, nil
+}
+
+// resolve answers config from the precomputed table, the cache or the
+// processor, in that order.
+func (handler Handler) resolve(ctx context.Context, snapshot *network.Snapshot, config dto.Configuration, queryTrace *trace) (dto.Result, error) {
+	trainSchedule, err := handler.schedule(snapshot, config)
+	if err != nil {
+		return dto.Result{}, err
+	}
+	return handler.resolveScheduled(ctx, snapshot, config, trainSchedule, queryTrace)
+}
+
+// resolveScheduled answers config, as resolve does, for trains running as
+// trainSchedule.
+func (handler Handler) resolveScheduled(ctx context.Context, snapshot *network.Snapshot, config dto.Configuration, trainSchedule schedule, queryTrace *trace) (dto.Result, error) {
+	result, err := handler.lookup(ctx, snapshot, config, trainSchedule, queryTrace)
+	if err != nil {
+		return dto.Result{}, err
+	}
+	return noteWindow(config, trainSchedule, result), nil
+}
+
+// lookup answers config, as resolve does, with what does not depend on the
+// departure time, since cached results are shared by every departure.
+func (handler Handler) lookup(ctx context.Context, snapshot *network.Snapshot, config dto.Configuration, trainSchedule schedule, queryTrace *trace) (dto.Result, error) {
+	// The table holds the routes of every service, so it can not answer
+	// days some of them do not run.
+	if handler.Table != nil && !trainSchedule.partial {
+		if path, towards, ok := handler.Table.Lookup(snapshot.Version(), config.InitialStation, config.FinalStation, trainSchedule.pattern); ok {
+			queryTrace.source = sourceTable
+			if path == nil {
+				return dto.Result{}, errors.New(e.ErrorInvalidCombination)
+			}
+			return describe(snapshot, config, trainSchedule.pattern, path, towards), nil
+		}
+	}
+
+	key := cache.Key{
+		Version:        snapshot.Version(),
+		InitialStation: config.InitialStation,
+		FinalStation:   config.FinalStation,
+		TrainColor:     config.TrainColor,
+		Options:        trainSchedule.key(config),
+	}
+	if handler.Cache != nil {
+		if entry, ok := handler.Cache.Get(key); ok {
+			queryTrace.source = sourceCache
+			if entry.Error != "" {
+				return dto.Result{}, errors.New(entry.Error)
+			}
+			return entry.Result, nil
+		}
+	}
+
+	queryTrace.source = sourceProcessor
+	result, err := handler.solve(ctx, snapshot, config, trainSchedule)
+	if handler.Cache != nil {
+		switch {
+		case err == nil:
+			handler.Cache.Add(key, cache.Entry{Result: result})
+		case err.Error() == e.ErrorInvalidCombination:
+			handler.Cache.Add(key, cache.Entry{Error: err.Error()})
+		}
+	}
+
+	return result, err
+}
+
+// Solve runs the processor pipeline over snapshot without consulting the
+// precomputed table or the cache.
+func (handler Handler) Solve(ctx context.Context, snapshot *network.Snapshot, config dto.Configuration) (dto.Result, error) {
+	trainSchedule, err := handler.schedule(snapshot, config)
+	if err != nil {
+		return dto.Result{}, err
+	}
+
+	result, err := handler.solve(ctx, snapshot, config, trainSchedule)
+	if err != nil {
+		return dto.Result{}, err
+	}
+	return noteWindow(config, trainSchedule, result), nil
+}
+
+// solve runs the processor pipeline for config with the trains running as
+// trainSchedule says.
+func (handler Handler) solve(ctx context.Context, snapshot *network.Snapshot, config dto.Configuration, trainSchedule schedule) (dto.Result, error) {
+	services := trainSchedule.services
+
+	stationsStart := time.Now()
+	routes, err := handler.routes(ctx, snapshot, trainSchedule.pattern, services)
+	handler.Metrics.ObserveStage(metrics.StageGetStations, stationsStart)
+	if err != nil {
+		return dto.Result{}, err
+	}
+
+	searchStart := time.Now()
+	defer func() {
+		handler.Metrics.ObserveStage(metrics.StageRouteSearch, searchStart)
+		handler.Logger.Debug(ctx, "route searched", logger.Fields{
+			"get_stations_ms": float64(searchStart.Sub(stationsStart).Microseconds()) / 1000,
+			"route_search_ms": logger.Milliseconds(searchStart),
+		})
+	}()
+
+	physicalRoutes, err := handler.lineRoutes(ctx, snapshot, handler.Configuration.GetTrainWithoutColor())
+	if err != nil {
+		return dto.Result{}, err
+	}
+
+	var shortestRoute []string
+	// Both ways around a circle run through every station, so the way with
+	// fewer stops may still be the longer one.
+	if snapshot.Circular() && len(services) == 0 {
+		shortestRoute, err = handler.Processor.GetShortestRouteAlong(ctx, routes, physicalRoutes, config.InitialStation, config.FinalStation)
+	} else {
+		shortestRoute, err = handler.Processor.GetShortestRoute(ctx, routes, config.InitialStation, config.FinalStation)
+	}
+	if err != nil {
+		return dto.Result{}, err
+	}
 	if shortestRoute == nil {
-		return nil, errors.New(e.ErrorInvalidCombination)
+		return dto.Result{}, errors.New(e.ErrorInvalidCombination)
+	}
+
+	route, err := handler.Processor.GetRoute(ctx, shortestRoute, config.InitialStation, config.FinalStation)
+	if err != nil {
+		return dto.Result{}, err
+	}
+
+	if len(services) == 0 {
+		physicalRoutes = runsAlong(routes, physicalRoutes, shortestRoute)
+	}
+
+	path, err := handler.Processor.GetPath(ctx, physicalRoutes, route)
+	if err != nil {
+		return dto.Result{}, err
+	}
+
+	towards, err := handler.Processor.GetTowards(ctx, physicalRoutes, route)
+	if err != nil {
+		return dto.Result{}, err
+	}
+	switch {
+	// A service turns back at its own ends, wherever the line ends.
+	case len(services) > 0:
+		towards = shortestRoute[len(shortestRoute)-1]
+	// A circle line has no terminals, so the direction is given by the next
+	// station instead.
+	case snapshot.Circular() && len(path) > 1:
+		towards = path[1].Name
+	}
+
+	return describe(snapshot, config, trainSchedule.pattern, path, towards), nil
+}
+
+// Stops returns the stations of the network where trainColor stops.
+func (handler Handler) Stops(ctx context.Context, snapshot *network.Snapshot, trainColor string) (map[string]bool, error) {
+	stops := map[string]bool{}
+	if services := snapshot.Services(trainColor); len(services) > 0 {
+		for _, service := range services {
+			for _, name := range service.Stops {
+				stops[name] = true
+			}
+		}
+		return stops, nil
+	}
+
+	stationsWithoutForks, forks, err := handler.Processor.GetStations(ctx, snapshot.Stations(), trainColor)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range stationsWithoutForks {
+		stops[name] = true
+	}
+	for _, fork := range forks {
+		for _, name := range fork {
+			stops[name] = true
+		}
+	}
+
+	return stops, nil
+}
+
+// routes returns the routes a trainColor train can follow: those of the
+// given services when it runs any, and otherwise those along the line.
+func (handler Handler) routes(ctx context.Context, snapshot *network.Snapshot, trainColor string, services []dto.Service) ([][]string, error) {
+	if len(services) == 0 {
+		return handler.lineRoutes(ctx, snapshot, trainColor)
+	}
+
+	physicalRoutes, err := handler.lineRoutes(ctx, snapshot, handler.Configuration.GetTrainWithoutColor())
+	if err != nil {
+		return nil, err
+	}
+	return handler.Processor.GetServiceRoutes(ctx, physicalRoutes, services)
+}
+
+// lineRoutes returns the routes along the line where trainColor stops,
+// including those between the branches of a fork. Those come first, so that
+// on a tie the shortest route search keeps the one that does not change
+// direction.
+func (handler Handler) lineRoutes(ctx context.Context, snapshot *network.Snapshot, trainColor string) ([][]string, error) {
+	stations := snapshot.Stations()
+	if snapshot.Circular() {
+		return handler.Processor.GetCircleRoutes(ctx, stations, trainColor)
+	}
+
+	routes, err := handler.Processor.GetRoutes(ctx, stations, trainColor)
+	if err != nil {
+		return nil, err
+	}
+
+	transferRoutes, err := handler.Processor.GetTransferRoutes(ctx, stations, trainColor)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(transferRoutes, routes...), nil
+}
+
+// runsAlong returns the physical routes a train stopping as route may run
+// along. The routes of every color line up, so they are those at the
+// positions of the routes equal to route. Branches without stops make
+// several of them equal.
+func runsAlong(routes, physicalRoutes [][]string, route []string) [][]string {
+	var along [][]string
+	for i, candidate := range routes {
+		if i < len(physicalRoutes) && equal(candidate, route) {
+			along = append(along, physicalRoutes[i])
+		}
 	}
+	return along
+}
 
-	route := handler.Processor.GetRoute(shortestRoute, config.InitialStation, config.FinalStation)
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
 
-	return route, nil
+func outcome(err error) string {
+	var timeout e.TimeoutError
+	switch {
+	case err == nil:
+		return metrics.OutcomeOK
+	case errors.As(err, &timeout):
+		return metrics.OutcomeTimeout
+	case err.Error() == e.ErrorInvalidCombination, err.Error() == e.ErrorNotRunning:
+		return metrics.OutcomeInvalidCombination
+	default:
+		return metrics.OutcomeReadError
+	}
 }
@@ -1,15 +1,25 @@
 package handler
 
 import (
+	"buda-challenge/cache"
+	"buda-challenge/calendar"
 	"buda-challenge/configuration"
 	"buda-challenge/dto"
+	"buda-challenge/logger"
+	"buda-challenge/network"
 	"buda-challenge/processor"
+	"buda-challenge/routetable"
 	"buda-challenge/validator"
 	e "buda-challenge/error"
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"strings"
 	"testing"
+	"time"
 )
 
 const (
@@ -32,11 +42,11 @@ func Test_WhenInitialStationIsFFinalStationIsBAndTrainColorIsGreen_ReturnStation
 		},
 	}
 
-	result, err := handler.HandleRequest()
+	result, err := handler.HandleRequest(context.Background())
 
 	resultExpected := []string{configuration.StationF, configuration.StationI, configuration.StationG, configuration.StationC, configuration.StationB}
 
-	assert.Equal(t, resultExpected, result)
+	assert.Equal(t, resultExpected, result.Stops)
 	assert.Nil(t, err)
 }
 
@@ -44,7 +54,453 @@ func Test_WhenInitialStationIsFFinalStationIsDAndTrainWithOutColor_ReturnStation
 	mockReader := new(MockReader)
 
 	mockReader.On(readInputMethodName, mock.Anything, mock.Anything).Return(getConfiguration(configuration.StationF, configuration.StationD, configuration.TrainWithoutColour), nil)
-	mockReader.On(readFileMethodName, mock.Anything).Return(getTrainNetwork())
+	mockReader.On(readFileMethodName, mock.Anything).Return(getTrainNetwork(), nil)
+
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+	}
+
+	result, err := handler.HandleRequest(context.Background())
+
+	resultExpected := []string{configuration.StationF, configuration.StationE, configuration.StationD}
+
+	assert.Equal(t, resultExpected, result.Stops)
+	assert.Nil(t, err)
+}
+
+func Test_WhenInitialStationIsAFinalStationIsFAndTrainWithOutColor_ReturnStationABCDEF(t *testing.T) {
+	mockReader := new(MockReader)
+
+	mockReader.On(readInputMethodName, mock.Anything, mock.Anything).Return(getConfiguration(configuration.StationA, configuration.StationF, configuration.TrainWithoutColour), nil)
+	mockReader.On(readFileMethodName, mock.Anything).Return(getTrainNetwork(), nil)
+
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+	}
+
+	result, err := handler.HandleRequest(context.Background())
+
+	resultExpected := []string{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationD, configuration.StationE, configuration.StationF}
+
+	assert.Equal(t, resultExpected, result.Stops)
+	assert.Nil(t, err)
+}
+
+func Test_WhenInitialStationIsAFinalStationIsFAndTrainColorIsRed_ReturnStationABCHF(t *testing.T) {
+	mockReader := new(MockReader)
+
+	mockReader.On(readInputMethodName, mock.Anything, mock.Anything).Return(getConfiguration(configuration.StationA, configuration.StationF, configuration.TrainRed), nil)
+	mockReader.On(readFileMethodName, mock.Anything).Return(getTrainNetwork(), nil)
+
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+	}
+
+	result, err := handler.HandleRequest(context.Background())
+
+	resultExpected := []string{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationH, configuration.StationF}
+
+	assert.Equal(t, resultExpected, result.Stops)
+	assert.Nil(t, err)
+}
+
+func Test_WhenInitialStationIsAFinalStationIsFAndTrainColorIsGreen_ReturnStationABCGIF(t *testing.T) {
+	mockReader := new(MockReader)
+
+	mockReader.On(readInputMethodName, mock.Anything, mock.Anything).Return(getConfiguration(configuration.StationA, configuration.StationF, configuration.TrainGreen), nil)
+	mockReader.On(readFileMethodName, mock.Anything).Return(getTrainNetwork(), nil)
+
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+	}
+
+	result, err := handler.HandleRequest(context.Background())
+
+	resultExpected := []string{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationG, configuration.StationI, configuration.StationF}
+
+	assert.Equal(t, resultExpected, result.Stops)
+	assert.Nil(t, err)
+}
+
+func Test_WhenInitialStationIsBFinalStationIsDAndTrainColorIsRed_ReturnStationABCDEF(t *testing.T) {
+	mockReader := new(MockReader)
+
+	mockReader.On(readInputMethodName, mock.Anything, mock.Anything).Return(getConfiguration(configuration.StationB, configuration.StationD, configuration.TrainRed), nil)
+	mockReader.On(readFileMethodName, mock.Anything).Return(getTrainNetwork(), nil)
+
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+	}
+
+	result, err := handler.HandleRequest(context.Background())
+
+	resultExpected := []string{configuration.StationB, configuration.StationC, configuration.StationD}
+
+	assert.Equal(t, resultExpected, result.Stops)
+	assert.Nil(t, err)
+}
+
+func Test_WhenGreenTrainGoesFromFToB_ReturnTheFullResult(t *testing.T) {
+	mockReader := new(MockReader)
+
+	mockReader.On(readFileMethodName, mock.Anything).Return(getTrainNetwork(), nil)
+
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+	}
+
+	result, err := handler.HandleQuery(context.Background(), getConfiguration(configuration.StationF, configuration.StationB, configuration.TrainGreen))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{configuration.StationF, configuration.StationI, configuration.StationG, configuration.StationC, configuration.StationB}, result.Stops)
+	assert.Equal(t, []string{configuration.StationH}, result.PassedThrough)
+	assert.Equal(t, []dto.PathStation{
+		{Name: configuration.StationF, Stop: true},
+		{Name: configuration.StationI, Stop: true},
+		{Name: configuration.StationH, Stop: false},
+		{Name: configuration.StationG, Stop: true},
+		{Name: configuration.StationC, Stop: true},
+		{Name: configuration.StationB, Stop: true},
+	}, result.Path)
+	assert.Equal(t, []dto.Segment{
+		{From: configuration.StationF, To: configuration.StationI, PassedThrough: []string{}, Distance: 1},
+		{From: configuration.StationI, To: configuration.StationG, PassedThrough: []string{configuration.StationH}, Distance: 2},
+		{From: configuration.StationG, To: configuration.StationC, PassedThrough: []string{}, Distance: 1},
+		{From: configuration.StationC, To: configuration.StationB, PassedThrough: []string{}, Distance: 1},
+	}, result.Segments)
+	assert.Equal(t, dto.Totals{Stops: 5, PassedThrough: 1, Segments: 4, Distance: 5}, result.Totals)
+	assert.Equal(t, configuration.TrainGreen, result.TrainColor)
+	assert.Equal(t, configuration.StationA, result.Towards)
+	assert.Len(t, result.NetworkVersion, 12)
+	assert.Equal(t, []string{"GREEN train passes through H without stopping"}, texts(result.Diagnostics))
+}
+
+func Test_WhenStationNamesAreNotAlphabetical_ReturnTheRouteInLineOrder(t *testing.T) {
+	mockReader := new(MockReader)
+
+	mockReader.On(readFileMethodName, mock.Anything).Return(dto.Network{Stations: []dto.Station{
+		{Name: "Tobalaba", TrainColor: configuration.TrainWithoutColour},
+		{Name: "Los Leones", TrainColor: configuration.TrainWithoutColour, Forks: [][]dto.Station{
+			{
+				{Name: "Manuel Montt", TrainColor: configuration.TrainWithoutColour},
+			},
+			{
+				{Name: "Salvador", TrainColor: configuration.TrainRed},
+				{Name: "Irarrázaval", TrainColor: configuration.TrainGreen},
+			},
+		}},
+		{Name: "Baquedano", TrainColor: configuration.TrainWithoutColour},
+		{Name: "Alameda", TrainColor: configuration.TrainWithoutColour},
+	}}, nil)
+
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+	}
+
+	result, err := handler.HandleQuery(context.Background(), getConfiguration("Alameda", "Tobalaba", configuration.TrainGreen))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Alameda", "Baquedano", "Irarrázaval", "Los Leones", "Tobalaba"}, result.Stops)
+	assert.Equal(t, []string{"Salvador"}, result.PassedThrough)
+	assert.Equal(t, "Tobalaba", result.Towards)
+}
+
+func Test_WhenThereAreTwoForksInSequence_EachColorChoosesTheBranchesWithFewerStops(t *testing.T) {
+	mockReader := new(MockReader)
+
+	mockReader.On(readFileMethodName, mock.Anything).Return(dto.Network{Stations: getNestedForksTrainNetwork()}, nil)
+
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+	}
+
+	green, err := handler.HandleQuery(context.Background(), getConfiguration("Tobalaba", "La Florida", configuration.TrainGreen))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Tobalaba", "Plaza de Puente Alto", "Las Mercedes", "Vicente Valdés", "Ñuble", "La Florida"}, green.Stops)
+	assert.Equal(t, []string{"Protectora", "Sótero del Río", "Bellavista"}, green.PassedThrough)
+
+	red, err := handler.HandleQuery(context.Background(), getConfiguration("Tobalaba", "La Florida", configuration.TrainRed))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Tobalaba", "Plaza de Puente Alto", "San José", "Vicente Valdés", "Mirador", "La Florida"}, red.Stops)
+	assert.Equal(t, []string{"Elisa Correa", "Rojas Magallanes", "Trinidad", "Macul"}, red.PassedThrough)
+	assert.Equal(t, "La Florida", red.Towards)
+}
+
+func Test_WhenTheStationIsInANestedFork_ReturnTheRouteThroughTheBranchesLeadingToIt(t *testing.T) {
+	mockReader := new(MockReader)
+
+	mockReader.On(readFileMethodName, mock.Anything).Return(dto.Network{Stations: getNestedForksTrainNetwork()}, nil)
+
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+	}
+
+	result, err := handler.HandleQuery(context.Background(), getConfiguration("Macul", "Tobalaba", configuration.TrainGreen))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Macul", "Mirador", "Trinidad", "Rojas Magallanes", "Vicente Valdés", "Las Mercedes", "Plaza de Puente Alto", "Tobalaba"}, result.Stops)
+	assert.Equal(t, []string{"Sótero del Río", "Protectora"}, result.PassedThrough)
+	assert.Equal(t, "Tobalaba", result.Towards)
+}
+
+func Test_WhenStationsAreOnBranchesEndingAtDifferentTerminals_ReturnTheRouteBackThroughTheJunction(t *testing.T) {
+	mockReader := new(MockReader)
+
+	mockReader.On(readFileMethodName, mock.Anything).Return(dto.Network{Stations: getYShapedTrainNetwork()}, nil)
+
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+	}
+
+	result, err := handler.HandleQuery(context.Background(), getConfiguration("Los Héroes", "Puente Cal y Canto", configuration.TrainGreen))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Los Héroes", "Baquedano", "Bellas Artes", "Puente Cal y Canto"}, result.Stops)
+	assert.Equal(t, []string{"Universidad de Chile"}, result.PassedThrough)
+	assert.Equal(t, "Puente Cal y Canto", result.Towards)
+
+	result, err = handler.HandleQuery(context.Background(), getConfiguration("Bellas Artes", "Tobalaba", configuration.TrainGreen))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Bellas Artes", "Baquedano", "Tobalaba"}, result.Stops)
+	assert.Equal(t, "Tobalaba", result.Towards)
+}
+
+func Test_WhenTheTrainColorDoesNotStopAtTheJunction_ReturnErrorBetweenBranches(t *testing.T) {
+	mockReader := new(MockReader)
+
+	stations := getYShapedTrainNetwork()
+	stations[1].TrainColor = configuration.TrainRed
+	mockReader.On(readFileMethodName, mock.Anything).Return(dto.Network{Stations: stations}, nil)
+
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+	}
+
+	_, err := handler.HandleQuery(context.Background(), getConfiguration("Los Héroes", "Puente Cal y Canto", configuration.TrainGreen))
+
+	assert.Equal(t, e.ErrorInvalidCombination, err.Error())
+}
+
+func Test_WhenTheLineIsACircle_ReturnTheShorterWayAroundIt(t *testing.T) {
+	mockReader := new(MockReader)
+
+	mockReader.On(readFileMethodName, mock.Anything).Return(dto.Network{Stations: getCircleTrainNetwork(), Circular: true}, nil)
+
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+	}
+
+	backward, err := handler.HandleQuery(context.Background(), getConfiguration("Tokyo", "Shibuya", configuration.TrainWithoutColour))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Tokyo", "Shinagawa", "Shibuya"}, backward.Stops)
+	assert.Equal(t, "Shinagawa", backward.Towards)
+
+	forward, err := handler.HandleQuery(context.Background(), getConfiguration("Shibuya", "Kanda", configuration.TrainWithoutColour))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Shibuya", "Shinagawa", "Tokyo", "Kanda"}, forward.Stops)
+	assert.Equal(t, "Shinagawa", forward.Towards)
+}
+
+func Test_WhenTheWayAroundTheCircleWithFewerStopsIsLonger_ReturnTheShorterWay(t *testing.T) {
+	mockReader := new(MockReader)
+
+	mockReader.On(readFileMethodName, mock.Anything).Return(dto.Network{Stations: []dto.Station{
+		{Name: configuration.StationA, TrainColor: configuration.TrainWithoutColour},
+		{Name: configuration.StationB, TrainColor: configuration.TrainWithoutColour},
+		{Name: configuration.StationC, TrainColor: configuration.TrainWithoutColour},
+		{Name: configuration.StationD, TrainColor: configuration.TrainRed},
+		{Name: configuration.StationE, TrainColor: configuration.TrainRed},
+		{Name: configuration.StationF, TrainColor: configuration.TrainRed},
+		{Name: configuration.StationG, TrainColor: configuration.TrainRed},
+		{Name: configuration.StationH, TrainColor: configuration.TrainRed},
+	}, Circular: true}, nil)
+
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+	}
+
+	result, err := handler.HandleQuery(context.Background(), getConfiguration(configuration.StationA, configuration.StationC, configuration.TrainGreen))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{configuration.StationA, configuration.StationB, configuration.StationC}, result.Stops)
+	assert.Equal(t, configuration.StationB, result.Towards)
+	assert.Equal(t, 2, result.Totals.Distance)
+}
+
+func Test_WhenTheColorStopsAtAStationInOneDirectionOfTheCircle_ReturnTheRouteGoingThatWay(t *testing.T) {
+	mockReader := new(MockReader)
+
+	mockReader.On(readFileMethodName, mock.Anything).Return(dto.Network{Stations: getCircleTrainNetwork(), Circular: true}, nil)
+
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+	}
+
+	longWay, err := handler.HandleQuery(context.Background(), getConfiguration("Tokyo", "Akihabara", configuration.TrainGreen))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Tokyo", "Shinagawa", "Shibuya", "Shinjuku", "Ikebukuro", "Ueno", "Akihabara"}, longWay.Stops)
+	assert.Equal(t, "Shinagawa", longWay.Towards)
+
+	shortWay, err := handler.HandleQuery(context.Background(), getConfiguration("Akihabara", "Tokyo", configuration.TrainGreen))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Akihabara", "Kanda", "Tokyo"}, shortWay.Stops)
+}
+
+func Test_WhenTheExpressSkipsAStationInOneDirection_StopThereOnlyInTheOther(t *testing.T) {
+	mockReader := new(MockReader)
+
+	mockReader.On(readFileMethodName, mock.Anything).Return(dto.Network{Stations: []dto.Station{
+		{Name: "Tobalaba", TrainColor: configuration.TrainWithoutColour},
+		{Name: "Los Leones", TrainColor: configuration.TrainGreen, TrainColors: map[string]string{dto.DirectionBackward: configuration.TrainRed}},
+		{Name: "Salvador", TrainColor: configuration.TrainWithoutColour},
+	}}, nil)
+
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+	}
+
+	outbound, err := handler.HandleQuery(context.Background(), getConfiguration("Tobalaba", "Salvador", configuration.TrainGreen))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Tobalaba", "Los Leones", "Salvador"}, outbound.Stops)
+
+	inbound, err := handler.HandleQuery(context.Background(), getConfiguration("Salvador", "Tobalaba", configuration.TrainGreen))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Salvador", "Tobalaba"}, inbound.Stops)
+	assert.Equal(t, []string{"Los Leones"}, inbound.PassedThrough)
+
+	_, err = handler.HandleQuery(context.Background(), getConfiguration("Salvador", "Los Leones", configuration.TrainGreen))
+
+	assert.Equal(t, e.ErrorInvalidCombination, err.Error())
+}
+
+func Test_WhenTheRedServiceTurnsBackAtD_ReturnRoutesUpToDOnly(t *testing.T) {
+	mockReader := new(MockReader)
+
+	trainNetwork := getTrainNetwork()
+	trainNetwork.Services = []dto.Service{{Color: configuration.TrainRed, Stops: []string{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationD}}}
+	mockReader.On(readFileMethodName, mock.Anything).Return(trainNetwork, nil)
+
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+	}
+
+	outbound, err := handler.HandleQuery(context.Background(), getConfiguration(configuration.StationA, configuration.StationD, configuration.TrainRed))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationD}, outbound.Stops)
+	assert.Equal(t, configuration.StationD, outbound.Towards)
+
+	inbound, err := handler.HandleQuery(context.Background(), getConfiguration(configuration.StationD, configuration.StationB, configuration.TrainRed))
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{configuration.StationD, configuration.StationC, configuration.StationB}, inbound.Stops)
+	assert.Equal(t, configuration.StationA, inbound.Towards)
+
+	_, err = handler.HandleQuery(context.Background(), getConfiguration(configuration.StationA, configuration.StationF, configuration.TrainRed))
+
+	assert.Equal(t, e.ErrorInvalidCombination, err.Error())
+
+	stops, err := handler.Stops(context.Background(), mustGetNetwork(t, handler), configuration.TrainRed)
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{configuration.StationA: true, configuration.StationB: true, configuration.StationC: true, configuration.StationD: true}, stops)
+}
+
+func Test_WhenTheServiceSkipsStations_ReturnThemAsPassedThrough(t *testing.T) {
+	mockReader := new(MockReader)
+
+	trainNetwork := getTrainNetwork()
+	trainNetwork.Services = []dto.Service{{Color: configuration.TrainGreen, Stops: []string{configuration.StationB, configuration.StationC, configuration.StationI, configuration.StationF}}}
+	mockReader.On(readFileMethodName, mock.Anything).Return(trainNetwork, nil)
 
 	handler := Handler{
 		Configuration: configuration.ConfigurationImpl{
@@ -55,19 +511,24 @@ func Test_WhenInitialStationIsFFinalStationIsDAndTrainWithOutColor_ReturnStation
 		},
 	}
 
-	result, err := handler.HandleRequest()
-
-	resultExpected := []string{configuration.StationF, configuration.StationE, configuration.StationD}
+	result, err := handler.HandleQuery(context.Background(), getConfiguration(configuration.StationF, configuration.StationB, configuration.TrainGreen))
 
-	assert.Equal(t, resultExpected, result)
 	assert.Nil(t, err)
+	assert.Equal(t, []string{configuration.StationF, configuration.StationI, configuration.StationC, configuration.StationB}, result.Stops)
+	assert.Equal(t, []string{configuration.StationH, configuration.StationG}, result.PassedThrough)
+	assert.Equal(t, configuration.StationB, result.Towards)
+
+	_, err = handler.HandleQuery(context.Background(), getConfiguration(configuration.StationA, configuration.StationF, configuration.TrainGreen))
+
+	assert.Equal(t, e.ErrorInvalidCombination, err.Error())
 }
 
-func Test_WhenInitialStationIsAFinalStationIsFAndTrainWithOutColor_ReturnStationABCDEF(t *testing.T) {
+func Test_WhenAServiceDoesNotRunAlongTheLine_ReturnError(t *testing.T) {
 	mockReader := new(MockReader)
 
-	mockReader.On(readInputMethodName, mock.Anything, mock.Anything).Return(getConfiguration(configuration.StationA, configuration.StationF, configuration.TrainWithoutColour), nil)
-	mockReader.On(readFileMethodName, mock.Anything).Return(getTrainNetwork())
+	trainNetwork := getTrainNetwork()
+	trainNetwork.Services = []dto.Service{{Color: configuration.TrainRed, Stops: []string{configuration.StationC, configuration.StationA, configuration.StationF}}}
+	mockReader.On(readFileMethodName, mock.Anything).Return(trainNetwork, nil)
 
 	handler := Handler{
 		Configuration: configuration.ConfigurationImpl{
@@ -78,19 +539,80 @@ func Test_WhenInitialStationIsAFinalStationIsFAndTrainWithOutColor_ReturnStation
 		},
 	}
 
-	result, err := handler.HandleRequest()
+	_, err := handler.HandleQuery(context.Background(), getConfiguration(configuration.StationA, configuration.StationF, configuration.TrainRed))
 
-	resultExpected := []string{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationD, configuration.StationE, configuration.StationF}
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), e.ErrorInvalidNetwork)
+}
+
+func Test_WhenTheExpressLeavesOutsideItsServiceWindow_ReturnTheAllStopsRoute(t *testing.T) {
+	mockReader := new(MockReader)
+
+	trainNetwork := getTrainNetwork()
+	trainNetwork.Colors = []dto.Color{{Name: configuration.TrainGreen, Windows: []dto.Window{{From: "07:00", To: "09:00"}}}}
+	mockReader.On(readFileMethodName, mock.Anything).Return(trainNetwork, nil)
+
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+		Cache: cache.NewLRU(10),
+	}
+
+	config := getConfiguration(configuration.StationA, configuration.StationF, configuration.TrainGreen)
+	config.DepartureTime = "08:15"
+	peak, err := handler.HandleQuery(context.Background(), config)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationG, configuration.StationI, configuration.StationF}, peak.Stops)
+	assert.Equal(t, configuration.TrainGreen, peak.ServicePattern)
+
+	config.DepartureTime = "12:00"
+	offPeak, err := handler.HandleQuery(context.Background(), config)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationD, configuration.StationE, configuration.StationF}, offPeak.Stops)
+	assert.Equal(t, configuration.TrainGreen, offPeak.TrainColor)
+	assert.Equal(t, configuration.TrainWithoutColour, offPeak.ServicePattern)
+	assert.Equal(t, []string{"GREEN trains stop as WITHOUT COLOR trains at 12:00, outside their service windows"}, texts(offPeak.Diagnostics))
+
+	config.DepartureTime = "22:30"
+	late, err := handler.HandleQuery(context.Background(), config)
 
-	assert.Equal(t, resultExpected, result)
 	assert.Nil(t, err)
+	assert.Equal(t, offPeak.Stops, late.Stops)
+	assert.Equal(t, []string{"GREEN trains stop as WITHOUT COLOR trains at 22:30, outside their service windows"}, texts(late.Diagnostics))
+
+	config.DepartureTime = ""
+	anyTime, err := handler.HandleQuery(context.Background(), config)
+
+	assert.Nil(t, err)
+	assert.Equal(t, peak.Stops, anyTime.Stops)
+	assert.Equal(t, configuration.TrainGreen, anyTime.ServicePattern)
+
+	config.DepartureTime = "noon"
+	_, err = handler.HandleQuery(context.Background(), config)
+
+	assert.Equal(t, e.ErrorReadingInput, err.Error())
 }
 
-func Test_WhenInitialStationIsAFinalStationIsFAndTrainColorIsRed_ReturnStationABCHF(t *testing.T) {
+func Test_WhenADateIsGiven_UseOnlyTheColorsAndServicesRunningThatDay(t *testing.T) {
 	mockReader := new(MockReader)
 
-	mockReader.On(readInputMethodName, mock.Anything, mock.Anything).Return(getConfiguration(configuration.StationA, configuration.StationF, configuration.TrainRed), nil)
-	mockReader.On(readFileMethodName, mock.Anything).Return(getTrainNetwork())
+	trainNetwork := getTrainNetwork()
+	trainNetwork.Colors = []dto.Color{{Name: configuration.TrainGreen, Calendar: "weekdays"}}
+	trainNetwork.Services = []dto.Service{
+		{Color: configuration.TrainRed, Stops: []string{configuration.StationA, configuration.StationC, configuration.StationD}},
+		{Color: configuration.TrainRed, Stops: []string{configuration.StationA, configuration.StationC, configuration.StationH, configuration.StationF}, Calendar: "weekdays"},
+	}
+	mockReader.On(readFileMethodName, mock.Anything).Return(trainNetwork, nil)
+
+	calendars, _ := calendar.New(dto.Calendars{Calendars: []dto.Calendar{
+		{ID: "weekdays", Weekdays: []string{"monday", "tuesday", "wednesday", "thursday", "friday"}, Removed: []string{"2026-09-18"}},
+	}})
 
 	handler := Handler{
 		Configuration: configuration.ConfigurationImpl{
@@ -99,21 +621,51 @@ func Test_WhenInitialStationIsAFinalStationIsFAndTrainColorIsRed_ReturnStationAB
 		Processor: processor.ProcessorImpl{
 			Validator: validator.ValidatorImpl{},
 		},
+		Cache:     cache.NewLRU(10),
+		Calendars: calendars,
 	}
 
-	result, err := handler.HandleRequest()
+	config := getConfiguration(configuration.StationA, configuration.StationF, configuration.TrainRed)
+	config.Date = "2026-10-19"
+	monday, err := handler.HandleQuery(context.Background(), config)
 
-	resultExpected := []string{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationH, configuration.StationF}
+	assert.Nil(t, err)
+	assert.Equal(t, []string{configuration.StationA, configuration.StationC, configuration.StationH, configuration.StationF}, monday.Stops)
+
+	config.Date = "2026-09-18"
+	_, err = handler.HandleQuery(context.Background(), config)
+
+	assert.Equal(t, e.ErrorInvalidCombination, err.Error())
+
+	config.FinalStation = configuration.StationD
+	holiday, err := handler.HandleQuery(context.Background(), config)
 
-	assert.Equal(t, resultExpected, result)
 	assert.Nil(t, err)
+	assert.Equal(t, []string{configuration.StationA, configuration.StationC, configuration.StationD}, holiday.Stops)
+
+	green := getConfiguration(configuration.StationA, configuration.StationF, configuration.TrainGreen)
+	green.Date = "2026-10-18"
+	_, err = handler.HandleQuery(context.Background(), green)
+
+	assert.Equal(t, e.ErrorNotRunning, err.Error())
+
+	green.Date = ""
+	_, err = handler.HandleQuery(context.Background(), green)
+
+	assert.Nil(t, err)
+
+	green.Date = "18/10/2026"
+	_, err = handler.HandleQuery(context.Background(), green)
+
+	assert.Equal(t, e.ErrorReadingInput, err.Error())
 }
 
-func Test_WhenInitialStationIsAFinalStationIsFAndTrainColorIsGreen_ReturnStationABCGIF(t *testing.T) {
+func Test_WhenTheNetworkNamesAnUnknownCalendar_ReturnErrorForDatedQueries(t *testing.T) {
 	mockReader := new(MockReader)
 
-	mockReader.On(readInputMethodName, mock.Anything, mock.Anything).Return(getConfiguration(configuration.StationA, configuration.StationF, configuration.TrainGreen), nil)
-	mockReader.On(readFileMethodName, mock.Anything).Return(getTrainNetwork())
+	trainNetwork := getTrainNetwork()
+	trainNetwork.Colors = []dto.Color{{Name: configuration.TrainGreen, Calendar: "weekdays"}}
+	mockReader.On(readFileMethodName, mock.Anything).Return(trainNetwork, nil)
 
 	handler := Handler{
 		Configuration: configuration.ConfigurationImpl{
@@ -124,19 +676,68 @@ func Test_WhenInitialStationIsAFinalStationIsFAndTrainColorIsGreen_ReturnStation
 		},
 	}
 
-	result, err := handler.HandleRequest()
+	config := getConfiguration(configuration.StationA, configuration.StationF, configuration.TrainWithoutColour)
+	config.Date = "2026-10-19"
+	_, err := handler.HandleQuery(context.Background(), config)
 
-	resultExpected := []string{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationG, configuration.StationI, configuration.StationF}
+	assert.NotNil(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), e.ErrorInvalidCalendar))
+}
+
+func Test_WhenHeadwaysAreGiven_ReturnTheExpectedTimeAgainstTheNextLocalTrain(t *testing.T) {
+	mockReader := new(MockReader)
+
+	trainNetwork := getTrainNetwork()
+	trainNetwork.Colors = []dto.Color{
+		{Name: configuration.TrainGreen, Headways: []dto.Headway{{From: "07:00", To: "09:00", Minutes: 4}, {Minutes: 20}}},
+		{Name: configuration.TrainWithoutColour, Headways: []dto.Headway{{Minutes: 12}}},
+	}
+	trainNetwork.Timing = &dto.Timing{RunMinutes: 2, StopMinutes: 1}
+	mockReader.On(readFileMethodName, mock.Anything).Return(trainNetwork, nil)
+
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+	}
+
+	config := getConfiguration(configuration.StationA, configuration.StationF, configuration.TrainGreen)
+	config.DepartureTime = "08:00"
+	peak, err := handler.HandleQuery(context.Background(), config)
+
+	assert.Nil(t, err)
+	assert.Equal(t, &dto.Expectation{TrainColor: configuration.TrainGreen, WaitMinutes: 2, RideMinutes: 16, TotalMinutes: 18}, peak.Expected)
+	assert.Equal(t, &dto.Expectation{TrainColor: configuration.TrainWithoutColour, WaitMinutes: 6, RideMinutes: 14, TotalMinutes: 20}, peak.Local)
+	assert.Equal(t, []string{"GREEN train passes through H without stopping"}, texts(peak.Diagnostics))
+
+	config.DepartureTime = "12:00"
+	offPeak, err := handler.HandleQuery(context.Background(), config)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 26.0, offPeak.Expected.TotalMinutes)
+	assert.Equal(t, 20.0, offPeak.Local.TotalMinutes)
+	assert.Contains(t, texts(offPeak.Diagnostics), "Taking the next WITHOUT COLOR train is expected to be faster: 20 minutes instead of 26")
+
+	config.DepartureTime = ""
+	anyTime, err := handler.HandleQuery(context.Background(), config)
 
-	assert.Equal(t, resultExpected, result)
 	assert.Nil(t, err)
+	assert.Nil(t, anyTime.Expected)
+	assert.Nil(t, anyTime.Local)
 }
 
-func Test_WhenInitialStationIsBFinalStationIsDAndTrainColorIsRed_ReturnStationABCDEF(t *testing.T) {
+func Test_WhenTheColorAlreadyStopsAsALocalTrain_DoNotCompareItWithTheLocalTrain(t *testing.T) {
 	mockReader := new(MockReader)
 
-	mockReader.On(readInputMethodName, mock.Anything, mock.Anything).Return(getConfiguration(configuration.StationB, configuration.StationD, configuration.TrainRed), nil)
-	mockReader.On(readFileMethodName, mock.Anything).Return(getTrainNetwork())
+	trainNetwork := getTrainNetwork()
+	trainNetwork.Colors = []dto.Color{
+		{Name: configuration.TrainGreen, Windows: []dto.Window{{From: "07:00", To: "09:00"}}, Headways: []dto.Headway{{Minutes: 20}}},
+		{Name: configuration.TrainWithoutColour, Headways: []dto.Headway{{Minutes: 12}}},
+	}
+	mockReader.On(readFileMethodName, mock.Anything).Return(trainNetwork, nil)
 
 	handler := Handler{
 		Configuration: configuration.ConfigurationImpl{
@@ -147,18 +748,21 @@ func Test_WhenInitialStationIsBFinalStationIsDAndTrainColorIsRed_ReturnStationAB
 		},
 	}
 
-	result, err := handler.HandleRequest()
+	config := getConfiguration(configuration.StationA, configuration.StationF, configuration.TrainGreen)
+	config.DepartureTime = "12:00"
+	result, err := handler.HandleQuery(context.Background(), config)
 
-	resultExpected := []string{configuration.StationB, configuration.StationC, configuration.StationD}
-
-	assert.Equal(t, resultExpected, result)
 	assert.Nil(t, err)
+	assert.Equal(t, configuration.TrainGreen, result.Expected.TrainColor)
+	assert.Nil(t, result.Local)
+	assert.Equal(t, []string{"GREEN trains stop as WITHOUT COLOR trains at 12:00, outside their service windows"}, texts(result.Diagnostics))
 }
 
 func Test_WhenInputCanNotBeRead_ReturnsError(t *testing.T) {
 	mockReader := new(MockReader)
 
 	mockReader.On(readInputMethodName, mock.Anything, mock.Anything).Return(nil, errors.New(e.ErrorReadingInput))
+	mockReader.On(readFileMethodName, mock.Anything).Return(getTrainNetwork(), nil)
 
 	handler := Handler{
 		Configuration: configuration.ConfigurationImpl{
@@ -169,13 +773,34 @@ func Test_WhenInputCanNotBeRead_ReturnsError(t *testing.T) {
 		},
 	}
 
-	result, err := handler.HandleRequest()
+	result, err := handler.HandleRequest(context.Background())
 
-	assert.Nil(t, result)
+	assert.Equal(t, dto.Result{}, result)
 	assert.NotNil(t, err)
 	assert.Equal(t, e.ErrorReadingInput, err.Error())
 }
 
+func Test_WhenRequestIsHandled_ReadTheNetworkOnceForTheInputAndTheRoute(t *testing.T) {
+	mockReader := new(MockReader)
+
+	mockReader.On(readInputMethodName, mock.Anything, mock.Anything).Return(getConfiguration(configuration.StationA, configuration.StationF, configuration.TrainRed), nil)
+	mockReader.On(readFileMethodName, mock.Anything).Return(getTrainNetwork(), nil)
+
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+	}
+
+	_, err := handler.HandleRequest(context.Background())
+
+	assert.Nil(t, err)
+	mockReader.AssertNumberOfCalls(t, readFileMethodName, 1)
+}
+
 func Test_WhenFileCanNotBeRead_ReturnsError(t *testing.T) {
 	mockReader := new(MockReader)
 
@@ -191,9 +816,9 @@ func Test_WhenFileCanNotBeRead_ReturnsError(t *testing.T) {
 		},
 	}
 
-	result, err := handler.HandleRequest()
+	result, err := handler.HandleRequest(context.Background())
 
-	assert.Nil(t, result)
+	assert.Equal(t, dto.Result{}, result)
 	assert.NotNil(t, err)
 	assert.Equal(t, e.ErrorReadingFile, err.Error())
 }
@@ -213,15 +838,208 @@ func Test_WhenInitialStationIsAFinalStationIsIAndTrainColorIsRed_ReturnError(t *
 		},
 	}
 
-	_, err := handler.HandleRequest()
+	_, err := handler.HandleRequest(context.Background())
 
 	assert.NotNil(t, err)
 	assert.Equal(t, e.ErrorInvalidCombination, err.Error())
 }
 
+func Test_WhenRedTrainDoesNotStopAtTheFinalStation_ReturnAJourneySwitchingTrains(t *testing.T) {
+	mockReader := new(MockReader)
+
+	mockReader.On(readFileMethodName, mock.Anything).Return(getTrainNetwork(), nil)
+
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+	}
+
+	journey, err := handler.PlanJourney(context.Background(), getConfiguration(configuration.StationA, configuration.StationI, configuration.TrainRed))
+
+	assert.Nil(t, err)
+	assert.Len(t, journey, 2)
+	assert.Equal(t, configuration.TrainRed, journey[0].TrainColor)
+	assert.Equal(t, []string{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationH}, journey[0].Stops)
+	assert.Equal(t, configuration.TrainWithoutColour, journey[1].TrainColor)
+	assert.Equal(t, []string{configuration.StationH, configuration.StationI}, journey[1].Stops)
+	assert.Equal(t, configuration.StationF, journey[1].Towards)
+}
+
+func Test_WhenTheTrainColorStopsAtBothStations_ReturnAJourneyOfOneLeg(t *testing.T) {
+	mockReader := new(MockReader)
+
+	mockReader.On(readFileMethodName, mock.Anything).Return(getTrainNetwork(), nil)
+
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+	}
+
+	journey, err := handler.PlanJourney(context.Background(), getConfiguration(configuration.StationF, configuration.StationB, configuration.TrainGreen))
+
+	assert.Nil(t, err)
+	assert.Len(t, journey, 1)
+	assert.Equal(t, []string{configuration.StationF, configuration.StationI, configuration.StationG, configuration.StationC, configuration.StationB}, journey[0].Stops)
+}
+
+func Test_WhenTimeoutExpiresBeforeTheRouteIsFound_ReturnTimeoutError(t *testing.T) {
+	mockReader := new(MockReader)
+
+	mockReader.On(readInputMethodName, mock.Anything, mock.Anything).Return(getConfiguration(configuration.StationA, configuration.StationF, configuration.TrainGreen), nil)
+	mockReader.On(readFileMethodName, mock.Anything).Return(getTrainNetwork(), nil)
+
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+		Timeout: time.Nanosecond,
+	}
+
+	result, err := handler.HandleRequest(context.Background())
+
+	var timeout e.TimeoutError
+	assert.Equal(t, dto.Result{}, result)
+	assert.True(t, errors.As(err, &timeout))
+	assert.Equal(t, e.ErrorTimeout, err.Error())
+}
+
+func Test_WhenRequestIsCanceled_ReturnCanceledError(t *testing.T) {
+	mockReader := new(MockReader)
+
+	mockReader.On(readInputMethodName, mock.Anything, mock.Anything).Return(getConfiguration(configuration.StationA, configuration.StationF, configuration.TrainGreen), nil)
+
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := handler.HandleRequest(ctx)
+
+	assert.Equal(t, dto.Result{}, result)
+	assert.Equal(t, e.ErrorCanceled, err.Error())
+}
+
+func Test_WhenRouteTableIsPrecomputed_ReturnTheSameRoutesAsSolvingEachQuery(t *testing.T) {
+	mockReader := new(MockReader)
+
+	mockReader.On(readFileMethodName, mock.Anything).Return(getTrainNetwork(), nil)
+
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+		Table: &routetable.Precomputed{},
+	}
+	handler.Table.Solver = handler
+
+	snapshot, err := handler.Configuration.GetNetwork(context.Background())
+	assert.Nil(t, err)
+	assert.Nil(t, handler.Table.Rebuild(context.Background(), snapshot))
+
+	for _, initialStation := range snapshot.StationNames() {
+		for _, finalStation := range snapshot.StationNames() {
+			for _, trainColor := range snapshot.Colors() {
+				config := getConfiguration(initialStation, finalStation, trainColor)
+
+				expected, expectedErr := handler.Solve(context.Background(), snapshot, config)
+				result, err := handler.HandleQuery(context.Background(), config)
+
+				assert.Equal(t, expected, result)
+				assert.Equal(t, expectedErr, err)
+			}
+		}
+	}
+}
+
+func Test_WhenTheSameQueryIsRepeated_ReturnItFromTheCache(t *testing.T) {
+	mockReader := new(MockReader)
+
+	mockReader.On(readFileMethodName, mock.Anything).Return(getTrainNetwork(), nil)
+
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+		Cache: cache.NewLRU(10),
+	}
+
+	config := getConfiguration(configuration.StationA, configuration.StationF, configuration.TrainGreen)
+	first, firstErr := handler.HandleQuery(context.Background(), config)
+	second, secondErr := handler.HandleQuery(context.Background(), config)
+
+	invalid := getConfiguration(configuration.StationA, configuration.StationI, configuration.TrainRed)
+	_, firstInvalidErr := handler.HandleQuery(context.Background(), invalid)
+	_, secondInvalidErr := handler.HandleQuery(context.Background(), invalid)
+
+	resultExpected := []string{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationG, configuration.StationI, configuration.StationF}
+
+	assert.Equal(t, resultExpected, first.Stops)
+	assert.Equal(t, first, second)
+	assert.Nil(t, firstErr)
+	assert.Nil(t, secondErr)
+	assert.Equal(t, e.ErrorInvalidCombination, firstInvalidErr.Error())
+	assert.Equal(t, e.ErrorInvalidCombination, secondInvalidErr.Error())
+	assert.Equal(t, cache.Stats{Hits: 2, Misses: 2, Entries: 2}, handler.Cache.Stats())
+}
+
+func Test_WhenQueryIsAnswered_LogItWithItsRequestID(t *testing.T) {
+	mockReader := new(MockReader)
+
+	mockReader.On(readFileMethodName, mock.Anything).Return(getTrainNetwork(), nil)
+
+	var output bytes.Buffer
+	handler := Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: mockReader,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+		Logger: logger.New(&output, logger.LevelInfo),
+	}
+
+	ctx := logger.WithRequestID(context.Background(), "request-1")
+	_, err := handler.HandleQuery(ctx, getConfiguration(configuration.StationF, configuration.StationD, configuration.TrainWithoutColour))
+
+	var entry map[string]interface{}
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(output.Bytes(), &entry))
+	assert.Equal(t, "query answered", entry["msg"])
+	assert.Equal(t, "request-1", entry["request_id"])
+	assert.Equal(t, configuration.StationF, entry["initial_station"])
+	assert.Equal(t, []interface{}{configuration.StationF, configuration.StationE, configuration.StationD}, entry["route"])
+	assert.Equal(t, "processor", entry["source"])
+	assert.Len(t, entry["network_version"], 12)
+	assert.Contains(t, entry, "duration_ms")
+}
+
 type MockReader struct { mock.Mock }
 
-func (s *MockReader) ReadInput(stations, colors []string) (dto.Configuration, error) {
+func (s *MockReader) ReadInput(ctx context.Context, stations, colors []dto.Option) (dto.Configuration, error) {
 	args := s.Called(stations, colors)
 
 	if args.Get(0) == nil {
@@ -231,18 +1049,18 @@ func (s *MockReader) ReadInput(stations, colors []string) (dto.Configuration, er
 	return args.Get(0).(dto.Configuration), nil
 }
 
-func (s *MockReader) ReadFile(fileName string) ([]dto.Station, error) {
+func (s *MockReader) ReadFile(ctx context.Context, fileName string) (dto.Network, error) {
 	args := s.Called(fileName)
 
 	if args.Get(0) == nil {
-		return []dto.Station{}, args.Error(1)
+		return dto.Network{}, args.Error(1)
 	}
 
-	return args.Get(0).([]dto.Station), nil
+	return args.Get(0).(dto.Network), nil
 }
 
-func (s *MockReader) Read(requiredValue string, validValues []string) (string, error) {
-	args := s.Called(requiredValue, validValues)
+func (s *MockReader) Read(ctx context.Context, requiredValue string, options []dto.Option) (string, error) {
+	args := s.Called(requiredValue, options)
 
 	if args.Get(0) == nil {
 		return "", args.Error(1)
@@ -251,6 +1069,23 @@ func (s *MockReader) Read(requiredValue string, validValues []string) (string, e
 	return args.Get(0).(string), nil
 }
 
+// texts returns the diagnostics in English.
+func texts(diagnostics []dto.Diagnostic) []string {
+	result := make([]string, len(diagnostics))
+	for i, diagnostic := range diagnostics {
+		result[i] = diagnostic.String()
+	}
+	return result
+}
+
+func mustGetNetwork(t *testing.T, handler Handler) *network.Snapshot {
+	snapshot, err := handler.Configuration.GetNetwork(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return snapshot
+}
+
 func getConfiguration(initialStation, finalStation, trainColor string) dto.Configuration {
 	return dto.Configuration{
 		InitialStation: initialStation,
@@ -259,7 +1094,74 @@ func getConfiguration(initialStation, finalStation, trainColor string) dto.Confi
 	}
 }
 
-func getTrainNetwork() []dto.Station {
+// getCircleTrainNetwork returns a circle line, in clockwise order. Green
+// trains stop at Akihabara only when running counterclockwise.
+func getCircleTrainNetwork() []dto.Station {
+	return []dto.Station{
+		{Name: "Tokyo", TrainColor: configuration.TrainWithoutColour},
+		{Name: "Kanda", TrainColor: configuration.TrainWithoutColour},
+		{Name: "Akihabara", TrainColor: configuration.TrainRed, TrainColors: map[string]string{dto.DirectionBackward: configuration.TrainGreen}},
+		{Name: "Ueno", TrainColor: configuration.TrainWithoutColour},
+		{Name: "Ikebukuro", TrainColor: configuration.TrainWithoutColour},
+		{Name: "Shinjuku", TrainColor: configuration.TrainWithoutColour},
+		{Name: "Shibuya", TrainColor: configuration.TrainWithoutColour},
+		{Name: "Shinagawa", TrainColor: configuration.TrainWithoutColour},
+	}
+}
+
+// getYShapedTrainNetwork returns a line that splits at Baquedano into two
+// branches ending at their own terminals.
+func getYShapedTrainNetwork() []dto.Station {
+	return []dto.Station{
+		{Name: "Tobalaba", TrainColor: configuration.TrainWithoutColour},
+		{Name: "Baquedano", TrainColor: configuration.TrainWithoutColour, Forks: [][]dto.Station{
+			{
+				{Name: "Universidad de Chile", TrainColor: configuration.TrainRed},
+				{Name: "Los Héroes", TrainColor: configuration.TrainWithoutColour},
+			},
+			{
+				{Name: "Bellas Artes", TrainColor: configuration.TrainWithoutColour},
+				{Name: "Puente Cal y Canto", TrainColor: configuration.TrainWithoutColour},
+			},
+		}},
+	}
+}
+
+// getNestedForksTrainNetwork returns a line that forks twice in sequence.
+// The second fork has a branch that forks again before rejoining the line.
+func getNestedForksTrainNetwork() []dto.Station {
+	return []dto.Station{
+		{Name: "Tobalaba", TrainColor: configuration.TrainWithoutColour},
+		{Name: "Plaza de Puente Alto", TrainColor: configuration.TrainWithoutColour, Forks: [][]dto.Station{
+			{
+				{Name: "Las Mercedes", TrainColor: configuration.TrainWithoutColour},
+				{Name: "Protectora", TrainColor: configuration.TrainRed},
+				{Name: "Sótero del Río", TrainColor: configuration.TrainRed},
+			},
+			{
+				{Name: "Elisa Correa", TrainColor: configuration.TrainGreen},
+				{Name: "San José", TrainColor: configuration.TrainWithoutColour},
+			},
+		}},
+		{Name: "Vicente Valdés", TrainColor: configuration.TrainWithoutColour, Forks: [][]dto.Station{
+			{
+				{Name: "Rojas Magallanes", TrainColor: configuration.TrainGreen},
+				{Name: "Trinidad", TrainColor: configuration.TrainGreen},
+				{Name: "Mirador", TrainColor: configuration.TrainWithoutColour, Forks: [][]dto.Station{
+					{{Name: "Macul", TrainColor: configuration.TrainGreen}},
+					{{Name: "Camino Agrícola", TrainColor: configuration.TrainRed}},
+				}},
+			},
+			{
+				{Name: "Bellavista", TrainColor: configuration.TrainRed},
+				{Name: "Ñuble", TrainColor: configuration.TrainWithoutColour},
+			},
+		}},
+		{Name: "La Florida", TrainColor: configuration.TrainWithoutColour},
+	}
+}
+
+func getTrainNetwork() dto.Network {
 	stationA := dto.Station{Name: configuration.StationA, Forks: nil, TrainColor: configuration.TrainWithoutColour}
 	stationB := dto.Station{Name: configuration.StationB, Forks: nil, TrainColor: configuration.TrainWithoutColour}
 	stationC := dto.Station{Name: configuration.StationC, Forks: [][]dto.Station{
@@ -297,7 +1199,7 @@ func getTrainNetwork() []dto.Station {
 	}
 	stationF := dto.Station{Name: configuration.StationF, Forks: nil, TrainColor: configuration.TrainWithoutColour}
 
-	return []dto.Station{stationA, stationB, stationC, stationF}
+	return dto.Network{Stations: []dto.Station{stationA, stationB, stationC, stationF}}
 }
 
 
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"buda-challenge/dto"
+	e "buda-challenge/error"
+	"buda-challenge/network"
+	"context"
+	"errors"
+)
+
+// PlanJourney answers config as HandleQuery does. When the train color does
+// not stop at the initial or final station, it plans instead a journey of
+// two legs that switches between that color and an all-stops train at the
+// station that keeps the journey shortest.
+func (handler Handler) PlanJourney(ctx context.Context, config dto.Configuration) ([]dto.Result, error) {
+	return handler.PlanSnapshotJourney(ctx, nil, config)
+}
+
+// PlanSnapshotJourney plans the journey on snapshot, or on the network being
+// served when it is nil, keeping the network its first query used for every
+// leg.
+func (handler Handler) PlanSnapshotJourney(ctx context.Context, snapshot *network.Snapshot, config dto.Configuration) ([]dto.Result, error) {
+	var queryTrace trace
+	result, err := handler.query(ctx, snapshot, config, &queryTrace)
+	if err == nil {
+		return []dto.Result{result}, nil
+	}
+
+	withoutColor := handler.Configuration.GetTrainWithoutColor()
+	if err.Error() != e.ErrorInvalidCombination || config.TrainColor == withoutColor {
+		return nil, err
+	}
+	snapshot = queryTrace.snapshot
+
+	var journey []dto.Result
+	for _, station := range snapshot.StationNames() {
+		if station == config.InitialStation || station == config.FinalStation {
+			continue
+		}
+
+		for _, colors := range [][2]string{{config.TrainColor, withoutColor}, {withoutColor, config.TrainColor}} {
+			first, legErr := handler.resolve(ctx, snapshot, leg(config, config.InitialStation, station, colors[0]), &trace{})
+			if legErr == nil {
+				var second dto.Result
+				second, legErr = handler.resolve(ctx, snapshot, leg(config, station, config.FinalStation, colors[1]), &trace{})
+				if legErr == nil && isShorter([]dto.Result{first, second}, journey, config.TrainColor) {
+					journey = []dto.Result{first, second}
+				}
+			}
+
+			var timeout e.TimeoutError
+			if errors.As(legErr, &timeout) {
+				return nil, legErr
+			}
+		}
+	}
+
+	if journey == nil {
+		return nil, err
+	}
+
+	return journey, nil
+}
+
+// leg returns the query for a leg of the journey asked by config. Every leg
+// runs on the date and leaves at the departure time of the journey.
+func leg(config dto.Configuration, initialStation, finalStation, trainColor string) dto.Configuration {
+	return dto.Configuration{
+		InitialStation: initialStation,
+		FinalStation:   finalStation,
+		TrainColor:     trainColor,
+		DepartureTime:  config.DepartureTime,
+		Date:           config.Date,
+	}
+}
+
+// isShorter reports whether journey covers less distance than best. On a
+// tie, the journey riding longer on trainColor wins.
+func isShorter(journey, best []dto.Result, trainColor string) bool {
+	if best == nil {
+		return true
+	}
+
+	distance, bestDistance := totalDistance(journey, ""), totalDistance(best, "")
+	if distance != bestDistance {
+		return distance < bestDistance
+	}
+
+	return totalDistance(journey, trainColor) > totalDistance(best, trainColor)
+}
+
+// totalDistance adds the distance of the legs on trainColor, or of every
+// leg when trainColor is empty.
+func totalDistance(journey []dto.Result, trainColor string) int {
+	distance := 0
+	for _, result := range journey {
+		if trainColor == "" || result.TrainColor == trainColor {
+			distance += result.Totals.Distance
+		}
+	}
+	return distance
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"buda-challenge/dto"
+	"buda-challenge/network"
+	"strings"
+)
+
+// describe builds the result of the physical path the processor chose,
+// travelled towards the given terminal by a train stopping as the pattern
+// color does.
+func describe(snapshot *network.Snapshot, config dto.Configuration, pattern string, path []dto.PathStation, towards string) dto.Result {
+	result := dto.Result{
+		Stops:          []string{},
+		PassedThrough:  []string{},
+		Path:           path,
+		Segments:       []dto.Segment{},
+		TrainColor:     config.TrainColor,
+		ServicePattern: pattern,
+		Towards:        towards,
+		NetworkVersion: snapshot.Version(),
+		Diagnostics:    []dto.Diagnostic{},
+	}
+
+	var segment *dto.Segment
+	for _, station := range path {
+		if !station.Stop {
+			result.PassedThrough = append(result.PassedThrough, station.Name)
+			if segment != nil {
+				segment.PassedThrough = append(segment.PassedThrough, station.Name)
+				segment.Distance++
+			}
+			continue
+		}
+
+		if segment != nil {
+			segment.To = station.Name
+			segment.Distance++
+			result.Segments = append(result.Segments, *segment)
+		}
+		result.Stops = append(result.Stops, station.Name)
+		segment = &dto.Segment{From: station.Name, PassedThrough: []string{}}
+	}
+
+	for _, segment := range result.Segments {
+		result.Totals.Distance += segment.Distance
+	}
+	result.Totals.Stops = len(result.Stops)
+	result.Totals.PassedThrough = len(result.PassedThrough)
+	result.Totals.Segments = len(result.Segments)
+
+	if len(result.PassedThrough) > 0 {
+		result.Diagnostics = append(result.Diagnostics, dto.NewDiagnostic("%s train passes through %s without stopping", config.TrainColor, strings.Join(result.PassedThrough, ", ")))
+	}
+
+	return result
+}
+
+// noteWindow tells, first among the diagnostics of result, when the trains
+// of config.TrainColor stop as another color because they leave outside
+// their service windows.
+func noteWindow(config dto.Configuration, trainSchedule schedule, result dto.Result) dto.Result {
+	if trainSchedule.pattern == config.TrainColor {
+		return result
+	}
+
+	note := dto.NewDiagnostic("%s trains stop as %s trains at %s, outside their service windows", config.TrainColor, trainSchedule.pattern, config.DepartureTime)
+	result.Diagnostics = append([]dto.Diagnostic{note}, result.Diagnostics...)
+	return result
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"buda-challenge/calendar"
+	"buda-challenge/dto"
+	e "buda-challenge/error"
+	"buda-challenge/network"
+	"errors"
+)
+
+// schedule is how the trains of the color of a query run on its date and at
+// its departure time.
+type schedule struct {
+	// pattern is the color whose stops the trains make.
+	pattern string
+	// services are those of pattern running on the date. Without them the
+	// trains stop where the stations of pattern say.
+	services []dto.Service
+	// partial is true when some services of pattern do not run on the date.
+	partial bool
+}
+
+// key tells apart in the cache queries for the same color answered with a
+// different schedule.
+func (s schedule) key(config dto.Configuration) string {
+	if s.partial {
+		return s.pattern + " " + config.Date
+	}
+	return s.pattern
+}
+
+// schedule returns how config.TrainColor trains run on config.Date when
+// they leave at config.DepartureTime: with their own stops within their
+// service windows and with every stop outside them, and by the services
+// running that day. It fails when the color does not run on that date.
+func (handler Handler) schedule(snapshot *network.Snapshot, config dto.Configuration) (schedule, error) {
+	applies, err := handler.applies(snapshot, config)
+	if err != nil {
+		return schedule{}, err
+	}
+
+	if !applies(snapshot.ColorCalendar(config.TrainColor)) {
+		return schedule{}, errors.New(e.ErrorNotRunning)
+	}
+
+	pattern := config.TrainColor
+	if config.DepartureTime != "" {
+		departure, err := network.ParseTimeOfDay(config.DepartureTime)
+		if err != nil {
+			return schedule{}, errors.New(e.ErrorReadingInput)
+		}
+		if !snapshot.RunsAt(config.TrainColor, departure, applies) {
+			pattern = handler.Configuration.GetTrainWithoutColor()
+		}
+	}
+
+	all := snapshot.Services(pattern)
+	var services []dto.Service
+	for _, service := range all {
+		if applies(service.Calendar) {
+			services = append(services, service)
+		}
+	}
+	if len(all) > 0 && len(services) == 0 {
+		return schedule{}, errors.New(e.ErrorNotRunning)
+	}
+
+	return schedule{pattern: pattern, services: services, partial: len(services) < len(all)}, nil
+}
+
+// applies returns whether a calendar runs on config.Date. Every calendar
+// does when there is no date.
+func (handler Handler) applies(snapshot *network.Snapshot, config dto.Configuration) (func(calendar string) bool, error) {
+	if config.Date == "" {
+		return func(string) bool { return true }, nil
+	}
+
+	date, err := calendar.ParseDate(config.Date)
+	if err != nil {
+		return nil, errors.New(e.ErrorReadingInput)
+	}
+	if err := handler.Calendars.Check(snapshot.Calendars()); err != nil {
+		return nil, err
+	}
+	return func(id string) bool {
+		return handler.Calendars.Runs(id, date)
+	}, nil
+}
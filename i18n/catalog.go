@@ -0,0 +1,90 @@
+package i18n
+
+import e "buda-challenge/error"
+
+// catalog maps every English message to its translation. English needs no
+// entry.
+var catalog = map[Language]map[string]string{
+	Spanish: {
+		// Reader prompts.
+		"initial station":                  "estación inicial",
+		"final station":                    "estación final",
+		"train color":                      "color del tren",
+		"Enter %s [ Valid values: %s ] : ": "Ingrese %s [ Valores válidos: %s ] : ",
+		"Invalid value! Try again!":        "¡Valor inválido! Intente nuevamente.",
+		"Invalid value!":                   "¡Valor inválido!",
+		"Invalid value! Did you mean %s?":  "¡Valor inválido! ¿Quiso decir %s?",
+
+		// Errors.
+		e.ErrorReadingInput:       "error al leer la entrada",
+		e.ErrorReadingFile:        "error al leer el archivo",
+		e.ErrorInvalidCombination: "combinación inválida",
+		e.ErrorInvalidNetwork:     "red inválida",
+		e.ErrorInvalidCalendar:    "calendario inválido",
+		e.ErrorNotRunning:         "el color del tren no circula en esa fecha",
+		e.ErrorTimeout:            "la solicitud excedió el tiempo límite",
+		e.ErrorCanceled:           "solicitud cancelada",
+
+		// Shell.
+		"Type help to list the commands.": "Escriba help para ver los comandos.",
+		"Usage":                           "Uso",
+		"Note:":                           "Nota:",
+		"Unknown command %q. Type help to list the commands.": "Comando %q desconocido. Escriba help para ver los comandos.",
+		"Unknown name %q.": "Nombre %q desconocido.",
+		"Did you mean %s?": "¿Quiso decir %s?",
+		"Enter the initial station, the final station and, optionally, the train color.": "Ingrese la estación inicial, la estación final y, opcionalmente, el color del tren.",
+		"There is no route yet, use route first.":                                        "Todavía no hay una ruta, use route primero.",
+		"%s: %s (%d stops, distance %d)":                                                 "%s: %s (%d paradas, distancia %d)",
+		"There is no network file to reload.":                                            "No hay un archivo de red para recargar.",
+		"Network %s loaded with %d stations.":                                            "Red %s cargada con %d estaciones.",
+		"find the shortest route, by a train without color unless a color is given":      "buscar la ruta más corta, en un tren sin color salvo que se indique un color",
+		"list the stations and the other names they are known by":                        "listar las estaciones y los otros nombres por los que se conocen",
+		"list the train colors":                                                          "listar los colores de tren",
+		"give step by step instructions for the last route":                              "dar instrucciones paso a paso para la última ruta",
+		"compare every train color between the stations of the last route":               "comparar cada color de tren entre las estaciones de la última ruta",
+		"draw the network":            "dibujar la red",
+		"read the network file again": "leer nuevamente el archivo de red",
+		"list the commands entered":   "listar los comandos ingresados",
+		"list the commands":           "listar los comandos",
+		"leave the shell":             "salir",
+
+		// Terminal interface.
+		"Arrows move, Enter chooses the origin and the destination, c changes the color, q quits.": "Las flechas mueven, Enter elige el origen y el destino, c cambia el color, q sale.",
+		"Origin":                  "Origen",
+		"Destination":             "Destino",
+		"Shortest route:":         "Ruta más corta:",
+		"Choose the origin.":      "Elija el origen.",
+		"Choose the destination.": "Elija el destino.",
+
+		// Web page.
+		"Metro route planner":           "Planificador de rutas de metro",
+		"Train color":                   "Color del tren",
+		"(passing %s without stopping)": "(pasando por %s sin detenerse)",
+		"Line diagram":                  "Diagrama de la red",
+
+		// Results.
+		"%s train passes through %s without stopping":                                 "El tren %s pasa por %s sin detenerse",
+		"%s trains stop as %s trains at %s, outside their service windows":            "Los trenes %s se detienen como trenes %s a las %s, fuera de sus horarios de servicio",
+		"Taking the next %s train is expected to be faster: %s minutes instead of %s": "Se espera que tomar el próximo tren %s sea más rápido: %s minutos en lugar de %s",
+		"Path:": "Recorrido:",
+		"Expected time: %s minutes, %s of them waiting": "Tiempo esperado: %s minutos, %s de ellos esperando",
+		"SEGMENT":                        "TRAMO",
+		"FROM":                           "DESDE",
+		"TO":                             "HASTA",
+		"PASSED THROUGH":                 "SIN PARADA",
+		"DISTANCE":                       "DISTANCIA",
+		"%d passed":                      "%d sin parada",
+		"%s train, %d stops, network %s": "Tren %s, %d paradas, red %s",
+
+		// Itinerary.
+		"Board a %s train at %s towards %s.":            "Suba a un tren %s en %s con dirección a %s.",
+		"Board a train without color at %s towards %s.": "Suba a un tren sin color en %s con dirección a %s.",
+		"Ride 1 stop":                   "Viaje 1 parada",
+		"Ride %d stops":                 "Viaje %d paradas",
+		", passing %s without stopping": ", pasando por %s sin detenerse",
+		"Alight at %s.":                 "Bájese en %s.",
+		"You are already at %s.":        "Ya se encuentra en %s.",
+		"%s trains do not stop at %s, so you need to switch trains at %s.": "Los trenes %s no se detienen en %s, así que debe cambiar de tren en %s.",
+		"and": "y",
+	},
+}
@@ -0,0 +1,39 @@
+package i18n
+
+import "strings"
+
+// localizedError keeps the original error available to errors.Is and
+// errors.As while showing a translated message.
+type localizedError struct {
+	message string
+	cause   error
+}
+
+func (l localizedError) Error() string {
+	return l.message
+}
+
+func (l localizedError) Unwrap() error {
+	return l.cause
+}
+
+// Error translates the message of err to language. Messages of the form
+// "known message: detail" get their known part translated.
+func Error(language Language, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	message := err.Error()
+	translated := Message(language, message)
+	if translated == message {
+		if i := strings.Index(message, ": "); i > 0 {
+			translated = Message(language, message[:i]) + message[i:]
+		}
+	}
+	if translated == message {
+		return err
+	}
+
+	return localizedError{message: translated, cause: err}
+}
@@ -0,0 +1,59 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Language string
+
+const (
+	English Language = "en"
+	Spanish Language = "es"
+)
+
+var languages = []Language{English, Spanish}
+
+// ParseLanguage accepts a language code such as "es" or a locale such as
+// "es_CL.UTF-8".
+func ParseLanguage(name string) (Language, error) {
+	code := strings.ToLower(name)
+	if i := strings.IndexAny(code, "_-.@"); i >= 0 {
+		code = code[:i]
+	}
+
+	for _, language := range languages {
+		if code == string(language) {
+			return language, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported language %q, valid values: en, es", name)
+}
+
+// Detect returns the language of the first locale variable set among
+// LC_ALL, LC_MESSAGES and LANG, as read by getenv. It falls back to English.
+func Detect(getenv func(string) string) Language {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := getenv(name)
+		if value == "" {
+			continue
+		}
+		if language, err := ParseLanguage(value); err == nil {
+			return language
+		}
+		break
+	}
+	return English
+}
+
+// Message translates message, written in English, to language and formats
+// it with args. Messages missing from the catalog are used as written.
+func Message(language Language, message string, args ...interface{}) string {
+	if translated, ok := catalog[language][message]; ok {
+		message = translated
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
@@ -0,0 +1,54 @@
+package i18n
+
+import (
+	e "buda-challenge/error"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_WhenLanguageIsALocale_ReturnItsLanguage(t *testing.T) {
+	language, err := ParseLanguage("es_CL.UTF-8")
+
+	assert.Nil(t, err)
+	assert.Equal(t, Spanish, language)
+
+	_, err = ParseLanguage("fr")
+
+	assert.NotNil(t, err)
+}
+
+func Test_WhenLocaleVariablesAreSet_DetectTheFirstOne(t *testing.T) {
+	env := map[string]string{"LC_MESSAGES": "es_ES.UTF-8", "LANG": "en_US.UTF-8"}
+
+	assert.Equal(t, Spanish, Detect(func(name string) string { return env[name] }))
+	assert.Equal(t, English, Detect(func(name string) string { return "" }))
+	assert.Equal(t, English, Detect(func(name string) string { return "C" }))
+}
+
+func Test_WhenMessageIsTranslated_ReturnItFormatted(t *testing.T) {
+	assert.Equal(t, "Ingrese estación inicial [ Valores válidos: A - B ] : ",
+		Message(Spanish, "Enter %s [ Valid values: %s ] : ", Message(Spanish, "initial station"), "A - B"))
+	assert.Equal(t, "Enter initial station [ Valid values: A - B ] : ",
+		Message(English, "Enter %s [ Valid values: %s ] : ", Message(English, "initial station"), "A - B"))
+	assert.Equal(t, "not in the catalog", Message(Spanish, "not in the catalog"))
+}
+
+func Test_WhenErrorIsTranslated_KeepTheOriginalError(t *testing.T) {
+	timeout := e.TimeoutError{Cause: context.DeadlineExceeded}
+
+	err := Error(Spanish, timeout)
+
+	assert.Equal(t, "la solicitud excedió el tiempo límite", err.Error())
+	assert.True(t, errors.As(err, &e.TimeoutError{}))
+	assert.Equal(t, timeout, Error(English, timeout))
+	assert.Nil(t, Error(Spanish, nil))
+}
+
+func Test_WhenErrorHasADetail_TranslateItsKnownPart(t *testing.T) {
+	err := Error(Spanish, fmt.Errorf("%s: no stations", e.ErrorInvalidNetwork))
+
+	assert.Equal(t, "red inválida: no stations", err.Error())
+}
@@ -0,0 +1,131 @@
+package itinerary
+
+import (
+	"buda-challenge/configuration"
+	"buda-challenge/dto"
+	"buda-challenge/i18n"
+	"buda-challenge/network"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Instructions returns one step per leg of the journey, naming stations and
+// colors as snapshot does in language. When the journey switches trains, a
+// first step explains why. A nil snapshot keeps the names of the journey.
+func Instructions(journey []dto.Result, snapshot *network.Snapshot, language i18n.Language) []string {
+	names := newLabels(snapshot, language)
+
+	var steps []string
+	if len(journey) > 1 {
+		steps = append(steps, switchStep(language, names, journey))
+	}
+	for _, result := range journey {
+		steps = append(steps, legStep(language, names, result))
+	}
+
+	return steps
+}
+
+// Write writes the numbered instructions of the journey.
+func Write(w io.Writer, journey []dto.Result, snapshot *network.Snapshot, language i18n.Language) error {
+	for i, step := range Instructions(journey, snapshot, language) {
+		if _, err := fmt.Fprintf(w, "%d. %s\n", i+1, step); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func legStep(language i18n.Language, names labels, result dto.Result) string {
+	if len(result.Stops) < 2 {
+		return i18n.Message(language, "You are already at %s.", names.station(first(result.Stops)))
+	}
+
+	var board string
+	if result.TrainColor == configuration.TrainWithoutColour {
+		board = i18n.Message(language, "Board a train without color at %s towards %s.", names.station(first(result.Stops)), names.station(result.Towards))
+	} else {
+		board = i18n.Message(language, "Board a %s train at %s towards %s.", names.color(result.TrainColor), names.station(first(result.Stops)), names.station(result.Towards))
+	}
+
+	ride := i18n.Message(language, "Ride 1 stop")
+	if rides := len(result.Stops) - 1; rides != 1 {
+		ride = i18n.Message(language, "Ride %d stops", rides)
+	}
+	if len(result.PassedThrough) > 0 {
+		passed := make([]string, len(result.PassedThrough))
+		for i, station := range result.PassedThrough {
+			passed[i] = names.station(station)
+		}
+		ride += i18n.Message(language, ", passing %s without stopping", join(passed, i18n.Message(language, "and")))
+	}
+
+	return board + " " + ride + ". " + i18n.Message(language, "Alight at %s.", names.station(last(result.Stops)))
+}
+
+// switchStep explains which end of the journey the chosen color skips and
+// where to change trains.
+func switchStep(language i18n.Language, names labels, journey []dto.Result) string {
+	firstLeg, lastLeg := journey[0], journey[len(journey)-1]
+
+	color, skipped := firstLeg.TrainColor, last(lastLeg.Stops)
+	if firstLeg.TrainColor == configuration.TrainWithoutColour {
+		color, skipped = lastLeg.TrainColor, first(firstLeg.Stops)
+	}
+
+	return i18n.Message(language, "%s trains do not stop at %s, so you need to switch trains at %s.", names.color(color), names.station(skipped), names.station(last(firstLeg.Stops)))
+}
+
+// labels names stations and colors in a language.
+type labels struct {
+	snapshot *network.Snapshot
+	language i18n.Language
+	stations map[string]string
+}
+
+func newLabels(snapshot *network.Snapshot, language i18n.Language) labels {
+	names := labels{snapshot: snapshot, language: language, stations: map[string]string{}}
+	if snapshot != nil {
+		for _, option := range snapshot.StationOptions(language) {
+			names.stations[option.Value] = option.Label
+		}
+	}
+	return names
+}
+
+func (l labels) station(name string) string {
+	if label, ok := l.stations[name]; ok {
+		return label
+	}
+	return name
+}
+
+func (l labels) color(color string) string {
+	if l.snapshot == nil {
+		return color
+	}
+	return l.snapshot.ColorOption(color, l.language).Label
+}
+
+// join lists values as "A, B and C".
+func join(values []string, and string) string {
+	if len(values) == 1 {
+		return values[0]
+	}
+	return strings.Join(values[:len(values)-1], ", ") + " " + and + " " + values[len(values)-1]
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func last(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[len(values)-1]
+}
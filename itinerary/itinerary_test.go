@@ -0,0 +1,120 @@
+package itinerary
+
+import (
+	"buda-challenge/configuration"
+	"buda-challenge/dto"
+	"buda-challenge/i18n"
+	"buda-challenge/network"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_WhenGreenTrainGoesFromFToB_ReturnTheInstructionsInEnglish(t *testing.T) {
+	steps := Instructions([]dto.Result{getGreenLeg()}, nil, i18n.English)
+
+	assert.Equal(t, []string{
+		"Board a GREEN train at F towards A. Ride 4 stops, passing H without stopping. Alight at B.",
+	}, steps)
+}
+
+func Test_WhenGreenTrainGoesFromFToB_ReturnTheInstructionsInSpanish(t *testing.T) {
+	steps := Instructions([]dto.Result{getGreenLeg()}, nil, i18n.Spanish)
+
+	assert.Equal(t, []string{
+		"Suba a un tren GREEN en F con dirección a A. Viaje 4 paradas, pasando por H sin detenerse. Bájese en B.",
+	}, steps)
+}
+
+func Test_WhenTheJourneySwitchesTrains_ExplainWhyFirst(t *testing.T) {
+	steps := Instructions(getSwitchingJourney(), nil, i18n.English)
+
+	assert.Equal(t, []string{
+		"RED trains do not stop at I, so you need to switch trains at H.",
+		"Board a RED train at A towards F. Ride 3 stops, passing G without stopping. Alight at H.",
+		"Board a train without color at H towards F. Ride 1 stop. Alight at I.",
+	}, steps)
+}
+
+func Test_WhenTheJourneySwitchesTrains_ExplainWhyFirstInSpanish(t *testing.T) {
+	steps := Instructions(getSwitchingJourney(), nil, i18n.Spanish)
+
+	assert.Equal(t, "Los trenes RED no se detienen en I, así que debe cambiar de tren en H.", steps[0])
+	assert.Equal(t, "Suba a un tren sin color en H con dirección a F. Viaje 1 parada. Bájese en I.", steps[2])
+}
+
+func Test_WhenTheNetworkHasLocalizedNames_UseThemInTheInstructions(t *testing.T) {
+	snapshot, err := network.NewSnapshot(getLocalizedNetwork(), time.Now())
+	assert.Nil(t, err)
+
+	steps := Instructions(getSwitchingJourney(), snapshot, i18n.Spanish)
+
+	assert.Equal(t, []string{
+		"Los trenes ROJO no se detienen en Isla, así que debe cambiar de tren en Hospital.",
+		"Suba a un tren ROJO en Alameda con dirección a F. Viaje 3 paradas, pasando por G sin detenerse. Bájese en Hospital.",
+		"Suba a un tren sin color en Hospital con dirección a F. Viaje 1 parada. Bájese en Isla.",
+	}, steps)
+}
+
+func Test_WhenTheJourneyIsWritten_NumberEachStep(t *testing.T) {
+	var output bytes.Buffer
+
+	err := Write(&output, getSwitchingJourney(), nil, i18n.English)
+
+	assert.Nil(t, err)
+	assert.Contains(t, output.String(), "1. RED trains do not stop at I")
+	assert.Contains(t, output.String(), "\n3. Board a train without color at H")
+}
+
+func getGreenLeg() dto.Result {
+	return dto.Result{
+		Stops:         []string{configuration.StationF, configuration.StationI, configuration.StationG, configuration.StationC, configuration.StationB},
+		PassedThrough: []string{configuration.StationH},
+		TrainColor:    configuration.TrainGreen,
+		Towards:       configuration.StationA,
+	}
+}
+
+func getSwitchingJourney() []dto.Result {
+	return []dto.Result{
+		{
+			Stops:         []string{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationH},
+			PassedThrough: []string{configuration.StationG},
+			TrainColor:    configuration.TrainRed,
+			Towards:       configuration.StationF,
+		},
+		{
+			Stops:      []string{configuration.StationH, configuration.StationI},
+			TrainColor: configuration.TrainWithoutColour,
+			Towards:    configuration.StationF,
+		},
+	}
+}
+
+func getLocalizedNetwork() dto.Network {
+	station := func(name, color, spanish string) dto.Station {
+		result := dto.Station{Name: name, TrainColor: color}
+		if spanish != "" {
+			result.Names = map[string]string{"es": spanish}
+		}
+		return result
+	}
+
+	return dto.Network{
+		Stations: []dto.Station{
+			station(configuration.StationA, configuration.TrainWithoutColour, "Alameda"),
+			station(configuration.StationB, configuration.TrainWithoutColour, ""),
+			station(configuration.StationC, configuration.TrainWithoutColour, ""),
+			station(configuration.StationG, configuration.TrainGreen, ""),
+			station(configuration.StationH, configuration.TrainRed, "Hospital"),
+			station(configuration.StationI, configuration.TrainGreen, "Isla"),
+			station(configuration.StationF, configuration.TrainWithoutColour, ""),
+		},
+		Colors: []dto.Color{{Name: configuration.TrainRed, Names: map[string]string{"es": "ROJO"}}},
+	}
+}
+
+func Test_WhenSeveralStationsArePassed_JoinThemWithTheLanguageConjunction(t *testing.T) {
+	assert.Equal(t, "D, E y G", join([]string{"D", "E", "G"}, "y"))
+}
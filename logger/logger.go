@@ -0,0 +1,143 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelOff
+)
+
+var levelNames = []string{"debug", "info", "warn", "error", "off"}
+
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelOff {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel accepts the names returned by Level.String, in any case.
+func ParseLevel(name string) (Level, error) {
+	for i, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return Level(i), nil
+		}
+	}
+	return LevelOff, fmt.Errorf("unknown log level %q, valid values: %s", name, strings.Join(levelNames, ", "))
+}
+
+// Fields are the structured values attached to a log entry.
+type Fields map[string]interface{}
+
+// Logger writes one JSON object per entry. A nil Logger discards everything.
+type Logger struct {
+	level Level
+	mutex sync.Mutex
+	out   io.Writer
+	now   func() time.Time
+}
+
+func New(out io.Writer, level Level) *Logger {
+	return &Logger{level: level, out: out, now: time.Now}
+}
+
+func (l *Logger) Enabled(level Level) bool {
+	return l != nil && level >= l.level && level < LevelOff
+}
+
+func (l *Logger) Debug(ctx context.Context, message string, fields Fields) {
+	l.log(ctx, LevelDebug, message, fields)
+}
+
+func (l *Logger) Info(ctx context.Context, message string, fields Fields) {
+	l.log(ctx, LevelInfo, message, fields)
+}
+
+func (l *Logger) Warn(ctx context.Context, message string, fields Fields) {
+	l.log(ctx, LevelWarn, message, fields)
+}
+
+func (l *Logger) Error(ctx context.Context, message string, fields Fields) {
+	l.log(ctx, LevelError, message, fields)
+}
+
+func (l *Logger) log(ctx context.Context, level Level, message string, fields Fields) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	entry := Fields{}
+	for key, value := range fields {
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		entry[key] = value
+	}
+	entry["time"] = l.now().UTC().Format(time.RFC3339Nano)
+	entry["level"] = level.String()
+	entry["msg"] = message
+	if id := RequestID(ctx); id != "" {
+		entry["request_id"] = id
+	}
+
+	content, err := json.Marshal(entry)
+	if err != nil {
+		content, _ = json.Marshal(Fields{"level": LevelError.String(), "msg": "unable to encode log entry", "error": err.Error()})
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	_, _ = l.out.Write(append(content, '\n'))
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying id, logged with every entry.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// EnsureRequestID returns ctx unchanged when it already has a request ID and
+// a copy with a new random one otherwise.
+func EnsureRequestID(ctx context.Context) context.Context {
+	if RequestID(ctx) != "" {
+		return ctx
+	}
+	return WithRequestID(ctx, NewRequestID())
+}
+
+func NewRequestID() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(id)
+}
+
+// Milliseconds returns the time elapsed since start, for duration fields.
+func Milliseconds(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000
+}
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_WhenEntryIsLogged_WriteItAsJSONWithTheRequestID(t *testing.T) {
+	var output bytes.Buffer
+	log := New(&output, LevelInfo)
+	log.now = func() time.Time { return time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC) }
+	ctx := WithRequestID(context.Background(), "abc")
+
+	log.Info(ctx, "route found", Fields{"route": []string{"A", "B"}, "error": errors.New("boom")})
+
+	assert.Equal(t, `{"error":"boom","level":"info","msg":"route found","request_id":"abc","route":["A","B"],"time":"2021-05-01T10:00:00Z"}`+"\n", output.String())
+}
+
+func Test_WhenEntryIsBelowTheLevel_DiscardIt(t *testing.T) {
+	var output bytes.Buffer
+	log := New(&output, LevelWarn)
+
+	log.Info(context.Background(), "ignored", nil)
+	log.Warn(context.Background(), "kept", nil)
+
+	var entry Fields
+	assert.Equal(t, 1, strings.Count(output.String(), "\n"))
+	assert.Nil(t, json.Unmarshal(output.Bytes(), &entry))
+	assert.Equal(t, "kept", entry["msg"])
+}
+
+func Test_WhenLoggerIsNil_DiscardEverything(t *testing.T) {
+	var log *Logger
+
+	assert.NotPanics(t, func() {
+		log.Error(context.Background(), "ignored", nil)
+	})
+	assert.False(t, log.Enabled(LevelError))
+}
+
+func Test_GivenALevelName_ReturnTheLevel(t *testing.T) {
+	level, err := ParseLevel("DEBUG")
+	assert.Nil(t, err)
+	assert.Equal(t, LevelDebug, level)
+
+	_, err = ParseLevel("verbose")
+	assert.NotNil(t, err)
+}
+
+func Test_WhenContextHasNoRequestID_EnsureAddsOne(t *testing.T) {
+	ctx := EnsureRequestID(context.Background())
+	same := EnsureRequestID(ctx)
+
+	assert.Len(t, RequestID(ctx), 16)
+	assert.Equal(t, RequestID(ctx), RequestID(same))
+}
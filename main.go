@@ -1,25 +1,289 @@
 package main
 
 import (
+	"buda-challenge/cache"
+	"buda-challenge/calendar"
 	"buda-challenge/configuration"
+	"buda-challenge/dto"
+	e "buda-challenge/error"
 	"buda-challenge/handler"
+	"buda-challenge/i18n"
+	"buda-challenge/logger"
+	"buda-challenge/metrics"
+	"buda-challenge/network"
 	"buda-challenge/processor"
 	"buda-challenge/reader"
+	"buda-challenge/render"
+	"buda-challenge/repl"
+	"buda-challenge/routetable"
+	"buda-challenge/server"
+	"buda-challenge/terminal"
+	"buda-challenge/tui"
 	"buda-challenge/validator"
+	"context"
+	"flag"
 	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
 )
 
 func main() {
-	result, err := handler.Handler{
+	timeout := flag.Duration("timeout", 0, "maximum time to compute the route once the input is read (0 disables it)")
+	networkFile := flag.String("network", configuration.TrainNetworkFilePath, "train network file")
+	precompute := flag.Bool("precompute", false, "precompute every route of the network and answer from that table")
+	dumpRoutes := flag.Bool("dump-routes", false, "print every precomputed route as a CSV origin-destination matrix and exit")
+	cacheSize := flag.Int("cache-size", 1024, "number of route results kept in memory (0 disables the cache)")
+	serve := flag.String("serve", "", "address to serve /route and /metrics on, e.g. :8080, instead of answering a single query")
+	watch := flag.Duration("watch", 0, "how often to check the network file for changes (0 disables polling, SIGHUP always reloads)")
+	output := flag.String("output", string(render.FormatText), "how to print the result: text, json, table or itinerary")
+	shell := flag.Bool("repl", false, "start an interactive shell that keeps the network loaded between queries")
+	showDiagram := flag.Bool("diagram", false, "also print the network as an ASCII diagram with the stops of the chosen color and the route")
+	fullScreen := flag.Bool("tui", false, "start a full-screen terminal interface to pick stations on the network diagram")
+	historyFile := flag.String("history-file", defaultHistoryFile(), "file keeping the shell history (empty keeps it in memory)")
+	lang := flag.String("lang", "", "language of prompts, messages and the itinerary: en or es (defaults to LC_ALL, LC_MESSAGES or LANG)")
+	departure := flag.String("departure", "", "departure time as HH:MM; outside the service windows of a color its trains stop at every station")
+	date := flag.String("date", "", "date of travel as YYYY-MM-DD; only the colors and services running that day are used")
+	calendarFile := flag.String("calendar", "", "service calendar file telling on which dates the calendars named by the network run")
+	logLevel := flag.String("log-level", envOrDefault("LOG_LEVEL", logger.LevelWarn.String()), "JSON log level written to stderr: debug, info, warn, error or off (LOG_LEVEL)")
+	flag.Parse()
+
+	level, err := logger.ParseLevel(*logLevel)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+	log := logger.New(os.Stderr, level)
+
+	format, err := render.ParseFormat(*output)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+
+	language := i18n.Detect(os.Getenv)
+	if *lang != "" {
+		language, err = i18n.ParseLanguage(*lang)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		cancel()
+	}()
+
+	fileReader := reader.ReaderImpl{
+		Validator: validator.ValidatorImpl{},
+		Logger:    log,
+		Language:  language,
+	}
+
+	var calendars *calendar.Calendars
+	if *calendarFile != "" {
+		calendars, err = calendar.Load(*calendarFile)
+		if err != nil {
+			_ = render.Render(os.Stdout, format, dto.Result{}, language, i18n.Error(language, err))
+			os.Exit(1)
+		}
+	}
+
+	store := network.NewStore(fileReader, *networkFile)
+	store.Logger = log
+	if calendars != nil {
+		store.Validate = func(snapshot *network.Snapshot) error {
+			return calendars.Check(snapshot.Calendars())
+		}
+	}
+	if err := store.Reload(ctx); err != nil {
+		_ = render.Render(os.Stdout, format, dto.Result{}, language, i18n.Error(language, err))
+		os.Exit(1)
+	}
+
+	requestHandler := handler.Handler{
 		Configuration: configuration.ConfigurationImpl{
-			Reader: reader.ReaderImpl{
-				Validator: validator.ValidatorImpl{},
-			},
+			Reader:        fileReader,
+			Store:         store,
+			Language:      language,
+			DepartureTime: *departure,
+			Date:          *date,
 		},
 		Processor: processor.ProcessorImpl{
 			Validator: validator.ValidatorImpl{},
+			Logger:    log,
 		},
-	}.HandleRequest()
+		Timeout:   *timeout,
+		Logger:    log,
+		Calendars: calendars,
+	}
+	if *cacheSize > 0 {
+		requestHandler.Cache = cache.NewLRU(*cacheSize)
+	}
+
+	if *precompute || *dumpRoutes {
+		requestHandler.Table = &routetable.Precomputed{Solver: requestHandler}
+		if err := requestHandler.Table.Rebuild(ctx, store.Current()); err != nil {
+			_ = render.Render(os.Stdout, format, dto.Result{}, language, i18n.Error(language, err))
+			os.Exit(1)
+		}
+		store.OnReload(func(snapshot *network.Snapshot) {
+			if err := requestHandler.Table.Rebuild(ctx, snapshot); err != nil {
+				log.Error(ctx, "keeping previous route table", logger.Fields{
+					"network_version": requestHandler.Table.Table().Version(),
+					"error":           err,
+				})
+			}
+		})
+	}
+
+	if *dumpRoutes {
+		if err := requestHandler.Table.Table().WriteCSV(os.Stdout); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	go store.Watch(ctx, *watch)
+
+	if *serve != "" {
+		httpServer := &http.Server{
+			Addr:    *serve,
+			Handler: server.New(requestHandler, store, metrics.NewRegistry()).Routes(),
+		}
+		go func() {
+			<-ctx.Done()
+			_ = httpServer.Shutdown(context.Background())
+		}()
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *shell {
+		history, err := reader.LoadHistory(*historyFile)
+		if err != nil {
+			log.Warn(ctx, "unable to read shell history", logger.Fields{"file": *historyFile, "error": err})
+			history, _ = reader.LoadHistory("")
+		}
+
+		editor := &reader.Editor{Input: os.Stdin, Output: os.Stdout, History: history}
+		if fd := int(os.Stdin.Fd()); terminal.IsTerminal(fd) {
+			editor.MakeRaw = func() (func() error, error) {
+				return terminal.MakeRaw(fd)
+			}
+		}
+
+		session := &repl.REPL{
+			Handler:       requestHandler,
+			Store:         store,
+			Editor:        editor,
+			Output:        os.Stdout,
+			Language:      language,
+			DepartureTime: *departure,
+			Date:          *date,
+		}
+		if err := session.Run(ctx); err != nil && e.FromContext(ctx) == nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *fullScreen {
+		fd := int(os.Stdin.Fd())
+		if !terminal.IsTerminal(fd) {
+			fmt.Println("the terminal interface needs a terminal")
+			os.Exit(2)
+		}
+
+		screen := &tui.TUI{
+			Handler: requestHandler,
+			Input:   os.Stdin,
+			Output:  os.Stdout,
+			MakeRaw: func() (func() error, error) {
+				return terminal.MakeRaw(fd)
+			},
+			Size: func() (int, int, error) {
+				return terminal.Size(int(os.Stdout.Fd()))
+			},
+			Language:      language,
+			DepartureTime: *departure,
+			Date:          *date,
+		}
+		if err := screen.Run(ctx); err != nil && e.FromContext(ctx) == nil {
+			_ = render.Render(os.Stdout, render.FormatText, dto.Result{}, language, i18n.Error(language, err))
+			os.Exit(1)
+		}
+		return
+	}
+
+	if format == render.FormatItinerary {
+		snapshot := store.Current()
+		config, err := requestHandler.ReadConfiguration(ctx, snapshot)
+		var journey []dto.Result
+		if err == nil {
+			journey, err = requestHandler.PlanSnapshotJourney(ctx, snapshot, config)
+		}
+
+		_ = render.RenderJourney(os.Stdout, journey, snapshot, language, i18n.Error(language, err))
+		if err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *showDiagram {
+		snapshot := store.Current()
+		config, err := requestHandler.ReadConfiguration(ctx, snapshot)
+		var result dto.Result
+		if err == nil {
+			result, err = requestHandler.HandleSnapshotQuery(ctx, snapshot, config)
+		}
+
+		_ = render.Render(os.Stdout, format, result, language, i18n.Error(language, err))
+		if config.TrainColor != "" {
+			stops, _ := requestHandler.Stops(ctx, snapshot, config.TrainColor)
+			fmt.Println()
+			_ = render.RenderDiagram(os.Stdout, snapshot, stops, result, config.TrainColor)
+		}
+		if err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
+	result, err := requestHandler.HandleRequest(ctx)
+
+	_ = render.Render(os.Stdout, format, result, language, i18n.Error(language, err))
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// defaultHistoryFile returns ~/.metro_history, or no file when the home
+// directory is unknown.
+func defaultHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".metro_history")
+}
 
-	fmt.Println("Shortest route: ", result, err)
-}
\ No newline at end of file
+func envOrDefault(name, value string) string {
+	if env := os.Getenv(name); env != "" {
+		return env
+	}
+	return value
+}
@@ -0,0 +1,154 @@
+package matcher
+
+import (
+	"buda-challenge/dto"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// accents maps accented lowercase letters to their base letter.
+var accents = map[rune]rune{
+	'á': 'a', 'à': 'a', 'ä': 'a', 'â': 'a', 'ã': 'a', 'å': 'a',
+	'é': 'e', 'è': 'e', 'ë': 'e', 'ê': 'e',
+	'í': 'i', 'ì': 'i', 'ï': 'i', 'î': 'i',
+	'ó': 'o', 'ò': 'o', 'ö': 'o', 'ô': 'o', 'õ': 'o',
+	'ú': 'u', 'ù': 'u', 'ü': 'u', 'û': 'u',
+	'ñ': 'n', 'ç': 'c',
+}
+
+// Normalize lowercases value, removes its accents and punctuation and
+// collapses its spaces, so that "Los Héroes" and "los  heroes" compare equal.
+func Normalize(value string) string {
+	normalized := strings.Map(func(r rune) rune {
+		r = unicode.ToLower(r)
+		if base, ok := accents[r]; ok {
+			return base
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return ' '
+	}, value)
+
+	return strings.Join(strings.Fields(normalized), " ")
+}
+
+// Names returns the normalized value, label and aliases of option.
+func Names(option dto.Option) []string {
+	names := []string{Normalize(option.Value), Normalize(option.Label)}
+	for _, alias := range option.Aliases {
+		names = append(names, Normalize(alias))
+	}
+	return names
+}
+
+// Match returns the option known as input once both are normalized.
+func Match(input string, options []dto.Option) (dto.Option, bool) {
+	normalized := Normalize(input)
+	for _, option := range options {
+		for _, name := range Names(option) {
+			if name == normalized {
+				return option, true
+			}
+		}
+	}
+
+	return dto.Option{}, false
+}
+
+// Autocomplete returns up to limit options for input: first, in their order,
+// the options with a name starting with input, then the options with a name
+// close enough to input to be a typo, closest first.
+func Autocomplete(input string, options []dto.Option, limit int) []dto.Option {
+	normalized := Normalize(input)
+	if normalized == "" || limit <= 0 {
+		return nil
+	}
+
+	var completions []dto.Option
+	type candidate struct {
+		option   dto.Option
+		distance int
+	}
+	var candidates []candidate
+
+	for _, option := range options {
+		best := -1
+		prefix := false
+		for _, name := range Names(option) {
+			if strings.HasPrefix(name, normalized) {
+				prefix = true
+				break
+			}
+			if distance := Distance(normalized, name); isTypo(distance, normalized, name) && (best < 0 || distance < best) {
+				best = distance
+			}
+		}
+
+		switch {
+		case prefix:
+			completions = append(completions, option)
+		case best >= 0:
+			candidates = append(candidates, candidate{option: option, distance: best})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+	for _, candidate := range candidates {
+		completions = append(completions, candidate.option)
+	}
+
+	if len(completions) > limit {
+		completions = completions[:limit]
+	}
+	return completions
+}
+
+// isTypo reports whether input is at distance from name few enough edits
+// to be a misspelling of it: one edit every three letters, and never the
+// whole name.
+func isTypo(distance int, input, name string) bool {
+	allowed := len([]rune(input)) / 3
+	if allowed < 1 {
+		allowed = 1
+	}
+	return distance <= allowed && distance < len([]rune(name))
+}
+
+// Distance returns the Levenshtein edit distance between a and b.
+func Distance(a, b string) int {
+	first, second := []rune(a), []rune(b)
+
+	previous := make([]int, len(second)+1)
+	current := make([]int, len(second)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(first); i++ {
+		current[0] = i
+		for j := 1; j <= len(second); j++ {
+			cost := 1
+			if first[i-1] == second[j-1] {
+				cost = 0
+			}
+			current[j] = minimum(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(second)]
+}
+
+func minimum(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+	return result
+}
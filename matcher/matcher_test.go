@@ -0,0 +1,61 @@
+package matcher
+
+import (
+	"buda-challenge/dto"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_GivenAccentsCaseAndPunctuation_NormalizeThemAway(t *testing.T) {
+	assert.Equal(t, "los heroes", Normalize("  Los  Héroes "))
+	assert.Equal(t, "nunoa", Normalize("ÑUÑOA"))
+	assert.Equal(t, "u de chile", Normalize("U. de Chile"))
+}
+
+func Test_WhenInputIsAnyNameOfAnOption_ReturnIt(t *testing.T) {
+	option, ok := Match("los heroes", getOptions())
+
+	assert.True(t, ok)
+	assert.Equal(t, "LH", option.Value)
+
+	option, ok = Match("bq", getOptions())
+
+	assert.True(t, ok)
+	assert.Equal(t, "BQ", option.Value)
+
+	_, ok = Match("Los", getOptions())
+
+	assert.False(t, ok)
+}
+
+func Test_WhenInputIsAPrefixOrATypo_AutocompleteIt(t *testing.T) {
+	assert.Equal(t, []string{"LH", "LL"}, values(Autocomplete("los", getOptions(), 3)))
+	assert.Equal(t, []string{"BQ"}, values(Autocomplete("Baqedano", getOptions(), 3)))
+	assert.Equal(t, []string{"LH", "LL"}, values(Autocomplete("los eroes", getOptions(), 3)))
+	assert.Equal(t, []string{"LH"}, values(Autocomplete("los", getOptions(), 1)))
+	assert.Empty(t, Autocomplete("Providencia", getOptions(), 3))
+	assert.Empty(t, Autocomplete("", getOptions(), 3))
+}
+
+func Test_GivenTwoWords_ReturnTheirEditDistance(t *testing.T) {
+	assert.Equal(t, 0, Distance("baquedano", "baquedano"))
+	assert.Equal(t, 1, Distance("baqedano", "baquedano"))
+	assert.Equal(t, 3, Distance("kitten", "sitting"))
+	assert.Equal(t, 4, Distance("", "ñuño"))
+}
+
+func getOptions() []dto.Option {
+	return []dto.Option{
+		{Value: "BQ", Label: "Baquedano"},
+		{Value: "LH", Label: "Los Héroes", Aliases: []string{"Heroes"}},
+		{Value: "LL", Label: "Los Leones"},
+	}
+}
+
+func values(options []dto.Option) []string {
+	result := make([]string, len(options))
+	for i, option := range options {
+		result[i] = option.Value
+	}
+	return result
+}
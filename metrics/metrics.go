@@ -0,0 +1,181 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Registry collects metrics and writes them in the Prometheus text
+// exposition format.
+type Registry struct {
+	mutex      sync.Mutex
+	collectors []collector
+}
+
+type collector interface {
+	write(w io.Writer)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Counter registers a counter partitioned by one label. The given label
+// values are exported as zero until they are incremented.
+func (r *Registry) Counter(name, help, label string, values ...string) *CounterVec {
+	counter := &CounterVec{name: name, help: help, label: label, values: map[string]float64{}}
+	for _, value := range values {
+		counter.values[value] = 0
+	}
+	r.register(counter)
+	return counter
+}
+
+// Histogram registers a histogram partitioned by one label.
+func (r *Registry) Histogram(name, help, label string, buckets []float64) *HistogramVec {
+	histogram := &HistogramVec{name: name, help: help, label: label, buckets: buckets, series: map[string]*series{}}
+	r.register(histogram)
+	return histogram
+}
+
+// Gauge registers a gauge whose value is read from value on every scrape.
+func (r *Registry) Gauge(name, help string, value func() float64) {
+	r.register(&gauge{name: name, help: help, value: value})
+}
+
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mutex.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mutex.Unlock()
+
+	var buffer bytes.Buffer
+	for _, c := range collectors {
+		c.write(&buffer)
+	}
+	return buffer.WriteTo(w)
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = r.WriteTo(w)
+}
+
+func (r *Registry) register(c collector) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+type CounterVec struct {
+	name   string
+	help   string
+	label  string
+	mutex  sync.Mutex
+	values map[string]float64
+}
+
+func (c *CounterVec) Inc(value string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.values[value]++
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	writeHeader(w, c.name, c.help, "counter")
+	for _, value := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s{%s=%q} %s\n", c.name, c.label, value, formatFloat(c.values[value]))
+	}
+}
+
+type HistogramVec struct {
+	name    string
+	help    string
+	label   string
+	buckets []float64
+	mutex   sync.Mutex
+	series  map[string]*series
+}
+
+type series struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func (h *HistogramVec) Observe(value string, observation float64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	s, ok := h.series[value]
+	if !ok {
+		s = &series{counts: make([]uint64, len(h.buckets))}
+		h.series[value] = s
+	}
+
+	for i, bucket := range h.buckets {
+		if observation <= bucket {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += observation
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	writeHeader(w, h.name, h.help, "histogram")
+	values := make([]string, 0, len(h.series))
+	for value := range h.series {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+
+	for _, value := range values {
+		s := h.series[value]
+		for i, bucket := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket{%s=%q,le=%q} %d\n", h.name, h.label, value, formatFloat(bucket), s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{%s=%q,le=\"+Inf\"} %d\n", h.name, h.label, value, s.count)
+		fmt.Fprintf(w, "%s_sum{%s=%q} %s\n", h.name, h.label, value, formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count{%s=%q} %d\n", h.name, h.label, value, s.count)
+	}
+}
+
+type gauge struct {
+	name  string
+	help  string
+	value func() float64
+}
+
+func (g *gauge) write(w io.Writer) {
+	writeHeader(w, g.name, g.help, "gauge")
+	fmt.Fprintf(w, "%s %s\n", g.name, formatFloat(g.value()))
+}
+
+func writeHeader(w io.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func sortedKeys(values map[string]float64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
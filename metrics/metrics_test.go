@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_GivenRecordedMetrics_WriteThemInPrometheusTextFormat(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.Counter("requests_total", "Requests.", "outcome", "ok", "error")
+	histogram := registry.Histogram("duration_seconds", "Duration.", "stage", []float64{0.1, 1})
+	registry.Gauge("stations", "Stations.", func() float64 { return 9 })
+
+	counter.Inc("ok")
+	counter.Inc("ok")
+	histogram.Observe("load", 0.05)
+	histogram.Observe("load", 0.5)
+
+	var output bytes.Buffer
+	_, err := registry.WriteTo(&output)
+
+	expected := `# HELP requests_total Requests.
+# TYPE requests_total counter
+requests_total{outcome="error"} 0
+requests_total{outcome="ok"} 2
+# HELP duration_seconds Duration.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{stage="load",le="0.1"} 1
+duration_seconds_bucket{stage="load",le="1"} 2
+duration_seconds_bucket{stage="load",le="+Inf"} 2
+duration_seconds_sum{stage="load"} 0.55
+duration_seconds_count{stage="load"} 2
+# HELP stations Stations.
+# TYPE stations gauge
+stations 9
+`
+	assert.Nil(t, err)
+	assert.Equal(t, expected, output.String())
+}
+
+func Test_WhenPlannerIsNil_RecordNothing(t *testing.T) {
+	var planner *Planner
+
+	assert.NotPanics(t, func() {
+		planner.ObserveRequest(OutcomeOK)
+		planner.ObserveStage(StageLoad, time.Now())
+	})
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"time"
+)
+
+const (
+	OutcomeOK                 = "ok"
+	OutcomeInvalidCombination = "invalid_combination"
+	OutcomeReadError          = "read_error"
+	OutcomeTimeout            = "timeout"
+
+	StageLoad        = "load"
+	StageGetStations = "get_stations"
+	StageRouteSearch = "route_search"
+)
+
+// DefaultBuckets cover route searches from a few microseconds to a second.
+var DefaultBuckets = []float64{0.00001, 0.00005, 0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1}
+
+// Planner groups the metrics recorded while answering queries. A nil
+// Planner records nothing.
+type Planner struct {
+	Requests *CounterVec
+	Stages   *HistogramVec
+}
+
+func NewPlanner(registry *Registry) *Planner {
+	return &Planner{
+		Requests: registry.Counter("metro_requests_total", "Queries answered by outcome.", "outcome",
+			OutcomeOK, OutcomeInvalidCombination, OutcomeReadError, OutcomeTimeout),
+		Stages: registry.Histogram("metro_stage_duration_seconds", "Time spent in each stage of the route pipeline.", "stage",
+			DefaultBuckets),
+	}
+}
+
+func (p *Planner) ObserveRequest(outcome string) {
+	if p == nil {
+		return
+	}
+	p.Requests.Inc(outcome)
+}
+
+// ObserveStage records the time elapsed since start for stage.
+func (p *Planner) ObserveStage(stage string, start time.Time) {
+	if p == nil {
+		return
+	}
+	p.Stages.Observe(stage, time.Since(start).Seconds())
+}
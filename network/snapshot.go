@@ -0,0 +1,509 @@
+package network
+
+import (
+	"buda-challenge/dto"
+	e "buda-challenge/error"
+	"buda-challenge/i18n"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Snapshot is an immutable, pre-indexed version of the train network. It is
+// safe to share between goroutines: every accessor returns copies.
+type Snapshot struct {
+	version  string
+	loadedAt time.Time
+	stations []dto.Station
+	circular bool
+	services []dto.Service
+	names    []string
+	colors   []string
+	index    map[string]int
+	// stationNames and colorNames hold the display names by language.
+	stationNames   map[string]map[string]string
+	colorNames     map[string]map[string]string
+	stationAliases map[string][]string
+	// windows holds the service windows by color.
+	windows map[string][]window
+	// colorCalendars holds the calendar of every color that has one.
+	colorCalendars map[string]string
+	// headways holds the headways by color.
+	headways map[string][]headway
+	timing   dto.Timing
+}
+
+// Default timing of networks that do not give theirs.
+const (
+	DefaultRunMinutes  = 2
+	DefaultStopMinutes = 1
+)
+
+// window is a time window as the time elapsed since midnight, and the
+// calendar of the days it applies on. A window from and to the same time
+// lasts all day.
+type window struct {
+	from, to time.Duration
+	calendar string
+}
+
+// contains reports whether the window includes departure.
+func (w window) contains(departure time.Duration) bool {
+	switch {
+	case w.from == w.to:
+		return true
+	case w.from < w.to:
+		return departure >= w.from && departure < w.to
+	default:
+		return departure >= w.from || departure < w.to
+	}
+}
+
+type headway struct {
+	window
+	minutes float64
+}
+
+// NewSnapshot validates network and builds a snapshot from a private copy of it.
+func NewSnapshot(network dto.Network, loadedAt time.Time) (*Snapshot, error) {
+	if err := Validate(network.Stations); err != nil {
+		return nil, err
+	}
+	if network.Circular {
+		if err := validateCircle(network.Stations); err != nil {
+			return nil, err
+		}
+	}
+	windows := map[string][]window{}
+	colorCalendars := map[string]string{}
+	headways := map[string][]headway{}
+	for _, color := range network.Colors {
+		if color.Name == "" {
+			return nil, fmt.Errorf("%s: color without name", e.ErrorInvalidNetwork)
+		}
+		for _, colorWindow := range color.Windows {
+			parsed, err := parseWindow(colorWindow)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s service window: %v", e.ErrorInvalidNetwork, color.Name, err)
+			}
+			windows[color.Name] = append(windows[color.Name], parsed)
+		}
+		if color.Calendar != "" {
+			colorCalendars[color.Name] = color.Calendar
+		}
+		for _, colorHeadway := range color.Headways {
+			parsed, err := parseHeadway(colorHeadway)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s headway: %v", e.ErrorInvalidNetwork, color.Name, err)
+			}
+			headways[color.Name] = append(headways[color.Name], parsed)
+		}
+	}
+
+	timing := dto.Timing{RunMinutes: DefaultRunMinutes, StopMinutes: DefaultStopMinutes}
+	if network.Timing != nil {
+		if network.Timing.RunMinutes <= 0 || network.Timing.StopMinutes < 0 {
+			return nil, fmt.Errorf("%s: timing needs positive run minutes and stop minutes that are not negative", e.ErrorInvalidNetwork)
+		}
+		timing = *network.Timing
+	}
+
+	if err := validateServices(network.Stations, network.Services); err != nil {
+		return nil, err
+	}
+
+	content, err := json.Marshal(network)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(content)
+
+	snapshot := &Snapshot{
+		version:        hex.EncodeToString(sum[:])[:12],
+		loadedAt:       loadedAt,
+		stations:       copyStations(network.Stations),
+		circular:       network.Circular,
+		services:       copyServices(network.Services),
+		index:          map[string]int{},
+		stationNames:   map[string]map[string]string{},
+		colorNames:     map[string]map[string]string{},
+		stationAliases: map[string][]string{},
+		windows:        windows,
+		colorCalendars: colorCalendars,
+		headways:       headways,
+		timing:         timing,
+	}
+
+	colors := map[string]bool{}
+	dto.Walk(snapshot.stations, func(station dto.Station) {
+		snapshot.index[station.Name] = len(snapshot.names)
+		snapshot.names = append(snapshot.names, station.Name)
+		snapshot.stationNames[station.Name] = station.Names
+		snapshot.stationAliases[station.Name] = append([]string{station.Code}, station.Aliases...)
+		for _, color := range append([]string{station.TrainColor}, directionColors(station)...) {
+			if !colors[color] {
+				colors[color] = true
+				snapshot.colors = append(snapshot.colors, color)
+			}
+		}
+	})
+	for _, service := range network.Services {
+		if !colors[service.Color] {
+			colors[service.Color] = true
+			snapshot.colors = append(snapshot.colors, service.Color)
+		}
+	}
+	for _, color := range network.Colors {
+		snapshot.colorNames[color.Name] = copyNames(color.Names)
+	}
+
+	return snapshot, nil
+}
+
+// Validate reports the first structural problem found in stations.
+func Validate(stations []dto.Station) error {
+	if len(stations) == 0 {
+		return fmt.Errorf("%s: no stations", e.ErrorInvalidNetwork)
+	}
+
+	seen := map[string]bool{}
+	codes := map[string]bool{}
+	var err error
+	dto.Walk(stations, func(station dto.Station) {
+		switch {
+		case err != nil:
+		case station.Name == "":
+			err = fmt.Errorf("%s: station without name", e.ErrorInvalidNetwork)
+		case seen[station.Name]:
+			err = fmt.Errorf("%s: duplicated station %q", e.ErrorInvalidNetwork, station.Name)
+		case station.Code != "" && codes[station.Code]:
+			err = fmt.Errorf("%s: duplicated station code %q", e.ErrorInvalidNetwork, station.Code)
+		case station.TrainColor == "":
+			err = fmt.Errorf("%s: station %q without train color", e.ErrorInvalidNetwork, station.Name)
+		default:
+			for direction := range station.TrainColors {
+				if direction != dto.DirectionForward && direction != dto.DirectionBackward {
+					err = fmt.Errorf("%s: station %q has train colors for unknown direction %q", e.ErrorInvalidNetwork, station.Name, direction)
+				}
+			}
+			for _, fork := range station.Forks {
+				if len(fork) == 0 {
+					err = fmt.Errorf("%s: station %q has an empty fork", e.ErrorInvalidNetwork, station.Name)
+				}
+			}
+		}
+		seen[station.Name] = true
+		codes[station.Code] = true
+	})
+
+	return err
+}
+
+// validateCircle reports why stations can not form a circle line.
+func validateCircle(stations []dto.Station) error {
+	if len(stations) < 3 {
+		return fmt.Errorf("%s: a circular line needs at least three stations", e.ErrorInvalidNetwork)
+	}
+	var err error
+	dto.Walk(stations, func(station dto.Station) {
+		if err == nil && len(station.Forks) > 0 {
+			err = fmt.Errorf("%s: circular line with forks at station %q", e.ErrorInvalidNetwork, station.Name)
+		}
+	})
+	return err
+}
+
+// validateServices reports the first service that does not stop at two or
+// more different stations of the network.
+func validateServices(stations []dto.Station, services []dto.Service) error {
+	names := map[string]bool{}
+	dto.Walk(stations, func(station dto.Station) {
+		names[station.Name] = true
+	})
+
+	for _, service := range services {
+		if service.Color == "" {
+			return fmt.Errorf("%s: service without color", e.ErrorInvalidNetwork)
+		}
+		if len(service.Stops) < 2 {
+			return fmt.Errorf("%s: %s service with less than two stops", e.ErrorInvalidNetwork, service.Color)
+		}
+		seen := map[string]bool{}
+		for _, stop := range service.Stops {
+			switch {
+			case !names[stop]:
+				return fmt.Errorf("%s: %s service stops at unknown station %q", e.ErrorInvalidNetwork, service.Color, stop)
+			case seen[stop]:
+				return fmt.Errorf("%s: %s service stops twice at station %q", e.ErrorInvalidNetwork, service.Color, stop)
+			}
+			seen[stop] = true
+		}
+	}
+	return nil
+}
+
+func parseWindow(colorWindow dto.Window) (window, error) {
+	from, err := ParseTimeOfDay(colorWindow.From)
+	if err != nil {
+		return window{}, err
+	}
+	to, err := ParseTimeOfDay(colorWindow.To)
+	if err != nil {
+		return window{}, err
+	}
+	if from == to {
+		return window{}, fmt.Errorf("window from %s to %s is empty", colorWindow.From, colorWindow.To)
+	}
+	return window{from: from, to: to, calendar: colorWindow.Calendar}, nil
+}
+
+func parseHeadway(colorHeadway dto.Headway) (headway, error) {
+	if colorHeadway.Minutes <= 0 {
+		return headway{}, fmt.Errorf("%v minutes between trains", colorHeadway.Minutes)
+	}
+	if colorHeadway.From == "" && colorHeadway.To == "" {
+		return headway{window: window{calendar: colorHeadway.Calendar}, minutes: colorHeadway.Minutes}, nil
+	}
+
+	parsed, err := parseWindow(dto.Window{From: colorHeadway.From, To: colorHeadway.To, Calendar: colorHeadway.Calendar})
+	if err != nil {
+		return headway{}, err
+	}
+	return headway{window: parsed, minutes: colorHeadway.Minutes}, nil
+}
+
+// ParseTimeOfDay returns the time elapsed since midnight at value, written
+// as HH:MM.
+func ParseTimeOfDay(value string) (time.Duration, error) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, use HH:MM", value)
+	}
+	return time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute, nil
+}
+
+// directionColors returns the train colors station declares by direction,
+// forward first.
+func directionColors(station dto.Station) []string {
+	var colors []string
+	for _, direction := range []string{dto.DirectionForward, dto.DirectionBackward} {
+		if color, ok := station.TrainColors[direction]; ok {
+			colors = append(colors, color)
+		}
+	}
+	return colors
+}
+
+// Version identifies the network content. Two snapshots built from the same
+// stations share the same version.
+func (s *Snapshot) Version() string {
+	return s.version
+}
+
+func (s *Snapshot) LoadedAt() time.Time {
+	return s.loadedAt
+}
+
+// Stations returns a copy of the network.
+func (s *Snapshot) Stations() []dto.Station {
+	return copyStations(s.stations)
+}
+
+// StationNames returns every station name, forks included, in file order.
+func (s *Snapshot) StationNames() []string {
+	return append([]string(nil), s.names...)
+}
+
+// Colors returns every train color used by the network, in file order.
+func (s *Snapshot) Colors() []string {
+	return append([]string(nil), s.colors...)
+}
+
+// Circular reports whether the last station connects back to the first.
+func (s *Snapshot) Circular() bool {
+	return s.circular
+}
+
+// Services returns the services run by trainColor, in file order. A color
+// without services stops where its stations say.
+func (s *Snapshot) Services(trainColor string) []dto.Service {
+	var services []dto.Service
+	for _, service := range s.services {
+		if service.Color == trainColor {
+			services = append(services, service)
+		}
+	}
+	return copyServices(services)
+}
+
+// RunsAt reports whether trainColor runs its own stopping pattern at
+// departure, the time elapsed since midnight, counting only the service
+// windows whose calendar applies. Colors without service windows always do.
+func (s *Snapshot) RunsAt(trainColor string, departure time.Duration, applies func(calendar string) bool) bool {
+	windows, ok := s.windows[trainColor]
+	if !ok {
+		return true
+	}
+
+	for _, window := range windows {
+		if applies(window.calendar) && window.contains(departure) {
+			return true
+		}
+	}
+	return false
+}
+
+// Headway returns the minutes between trainColor trains leaving at
+// departure, the time elapsed since midnight, by the first of its headways
+// whose calendar applies and whose window includes departure. It returns
+// false when none does.
+func (s *Snapshot) Headway(trainColor string, departure time.Duration, applies func(calendar string) bool) (float64, bool) {
+	for _, headway := range s.headways[trainColor] {
+		if applies(headway.calendar) && headway.contains(departure) {
+			return headway.minutes, true
+		}
+	}
+	return 0, false
+}
+
+// Timing returns how long trains take between stations.
+func (s *Snapshot) Timing() dto.Timing {
+	return s.timing
+}
+
+// ColorCalendar returns the calendar of the days trainColor runs, empty when
+// it runs every day.
+func (s *Snapshot) ColorCalendar(trainColor string) string {
+	return s.colorCalendars[trainColor]
+}
+
+// Calendars returns every calendar the network names, sorted.
+func (s *Snapshot) Calendars() []string {
+	named := map[string]bool{}
+	for _, calendar := range s.colorCalendars {
+		named[calendar] = true
+	}
+	for _, windows := range s.windows {
+		for _, window := range windows {
+			named[window.calendar] = true
+		}
+	}
+	for _, headways := range s.headways {
+		for _, headway := range headways {
+			named[headway.calendar] = true
+		}
+	}
+	for _, service := range s.services {
+		named[service.Calendar] = true
+	}
+	delete(named, "")
+
+	calendars := make([]string, 0, len(named))
+	for calendar := range named {
+		calendars = append(calendars, calendar)
+	}
+	sort.Strings(calendars)
+	return calendars
+}
+
+func (s *Snapshot) HasStation(name string) bool {
+	_, ok := s.index[name]
+	return ok
+}
+
+// StationOptions returns every station, in the order of StationNames,
+// labeled with its name in language.
+func (s *Snapshot) StationOptions(language i18n.Language) []dto.Option {
+	options := make([]dto.Option, len(s.names))
+	for i, name := range s.names {
+		options[i] = option(name, s.stationNames[name], s.stationAliases[name], language)
+	}
+	return options
+}
+
+// ColorOption returns color labeled with its name in language.
+func (s *Snapshot) ColorOption(color string, language i18n.Language) dto.Option {
+	return option(color, s.colorNames[color], nil, language)
+}
+
+// option labels value with its name in language and accepts its names in
+// every other language, then aliases, as aliases.
+func option(value string, names map[string]string, aliases []string, language i18n.Language) dto.Option {
+	label := value
+	if name, ok := names[string(language)]; ok && name != "" {
+		label = name
+	}
+
+	languages := make([]string, 0, len(names))
+	for language := range names {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+
+	result := dto.Option{Value: value, Label: label}
+	seen := map[string]bool{label: true}
+	others := []string{value}
+	for _, language := range languages {
+		others = append(others, names[language])
+	}
+	for _, alias := range append(others, aliases...) {
+		if alias != "" && !seen[alias] {
+			seen[alias] = true
+			result.Aliases = append(result.Aliases, alias)
+		}
+	}
+
+	return result
+}
+
+func copyStations(stations []dto.Station) []dto.Station {
+	if stations == nil {
+		return nil
+	}
+
+	copied := make([]dto.Station, len(stations))
+	for i, station := range stations {
+		copied[i] = station
+		copied[i].Names = copyNames(station.Names)
+		copied[i].TrainColors = copyNames(station.TrainColors)
+		if station.Aliases != nil {
+			copied[i].Aliases = append([]string(nil), station.Aliases...)
+		}
+		if station.Forks != nil {
+			copied[i].Forks = make([][]dto.Station, len(station.Forks))
+			for j, fork := range station.Forks {
+				copied[i].Forks[j] = copyStations(fork)
+			}
+		}
+	}
+
+	return copied
+}
+
+func copyServices(services []dto.Service) []dto.Service {
+	if services == nil {
+		return nil
+	}
+
+	copied := make([]dto.Service, len(services))
+	for i, service := range services {
+		copied[i] = service
+		copied[i].Stops = append([]string(nil), service.Stops...)
+	}
+	return copied
+}
+
+func copyNames(names map[string]string) map[string]string {
+	if names == nil {
+		return nil
+	}
+
+	copied := make(map[string]string, len(names))
+	for language, name := range names {
+		copied[language] = name
+	}
+	return copied
+}
@@ -0,0 +1,260 @@
+package network
+
+import (
+	"buda-challenge/dto"
+	e "buda-challenge/error"
+	"buda-challenge/i18n"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_GivenAValidNetwork_ReturnIndexedSnapshot(t *testing.T) {
+	snapshot, err := NewSnapshot(dto.Network{Stations: getStations()}, time.Now())
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{stationA, stationB, stationC, stationD, stationE, stationG, stationH, stationI, stationF}, snapshot.StationNames())
+	assert.Equal(t, []string{trainWithoutColour, trainGreen, trainRed}, snapshot.Colors())
+	assert.True(t, snapshot.HasStation(stationH))
+	assert.False(t, snapshot.HasStation("Z"))
+	assert.Len(t, snapshot.Version(), 12)
+}
+
+func Test_GivenTheSameNetworkTwice_ReturnTheSameVersion(t *testing.T) {
+	first, _ := NewSnapshot(dto.Network{Stations: getStations()}, time.Now())
+	second, _ := NewSnapshot(dto.Network{Stations: getStations()}, time.Now().Add(time.Hour))
+
+	changed := getStations()
+	changed[0].TrainColor = trainRed
+	third, _ := NewSnapshot(dto.Network{Stations: changed}, time.Now())
+
+	assert.Equal(t, first.Version(), second.Version())
+	assert.NotEqual(t, first.Version(), third.Version())
+}
+
+func Test_WhenStationsReturnedAreModified_SnapshotDoesNotChange(t *testing.T) {
+	stations := getStations()
+	snapshot, _ := NewSnapshot(dto.Network{Stations: stations}, time.Now())
+
+	stations[2].Forks[1][0].Name = "Z"
+	returned := snapshot.Stations()
+	returned[2].Forks[0][0].Name = "Z"
+
+	assert.Equal(t, getStations(), snapshot.Stations())
+}
+
+func Test_GivenAnInvalidNetwork_ReturnError(t *testing.T) {
+	duplicated := getStations()
+	duplicated[3].Name = stationA
+
+	withoutColor := getStations()
+	withoutColor[1].TrainColor = ""
+
+	emptyFork := getStations()
+	emptyFork[2].Forks = append(emptyFork[2].Forks, []dto.Station{})
+
+	for _, stations := range [][]dto.Station{nil, duplicated, withoutColor, emptyFork} {
+		snapshot, err := NewSnapshot(dto.Network{Stations: stations}, time.Now())
+
+		assert.Nil(t, snapshot)
+		assert.NotNil(t, err)
+		assert.True(t, strings.HasPrefix(err.Error(), e.ErrorInvalidNetwork))
+	}
+}
+
+func Test_GivenACircularLineWithColorsByDirection_ReturnItsSnapshot(t *testing.T) {
+	stations := []dto.Station{
+		{Name: stationA, TrainColor: trainWithoutColour},
+		{Name: stationB, TrainColor: trainRed, TrainColors: map[string]string{dto.DirectionBackward: trainGreen}},
+		{Name: stationC, TrainColor: trainWithoutColour},
+	}
+
+	snapshot, err := NewSnapshot(dto.Network{Stations: stations, Circular: true}, time.Now())
+	linear, _ := NewSnapshot(dto.Network{Stations: stations}, time.Now())
+
+	assert.Nil(t, err)
+	assert.True(t, snapshot.Circular())
+	assert.False(t, linear.Circular())
+	assert.NotEqual(t, linear.Version(), snapshot.Version())
+	assert.Equal(t, []string{trainWithoutColour, trainRed, trainGreen}, snapshot.Colors())
+}
+
+func Test_GivenAnInvalidCircularLine_ReturnError(t *testing.T) {
+	unknownDirection := []dto.Station{
+		{Name: stationA, TrainColor: trainWithoutColour},
+		{Name: stationB, TrainColor: trainRed, TrainColors: map[string]string{"clockwise": trainGreen}},
+		{Name: stationC, TrainColor: trainWithoutColour},
+	}
+
+	for _, stations := range [][]dto.Station{getStations(), getStations()[:2], unknownDirection} {
+		snapshot, err := NewSnapshot(dto.Network{Stations: stations, Circular: true}, time.Now())
+
+		assert.Nil(t, snapshot)
+		assert.NotNil(t, err)
+		assert.True(t, strings.HasPrefix(err.Error(), e.ErrorInvalidNetwork))
+	}
+}
+
+func Test_GivenServices_ReturnThoseOfEachColor(t *testing.T) {
+	services := []dto.Service{
+		{Color: trainRed, Stops: []string{stationA, stationB, stationC, stationD}},
+		{Color: "BLUE", Stops: []string{stationA, stationC}},
+		{Color: trainRed, Stops: []string{stationC, stationH, stationF}},
+	}
+
+	snapshot, err := NewSnapshot(dto.Network{Stations: getStations(), Services: services}, time.Now())
+
+	assert.Nil(t, err)
+	assert.Equal(t, []dto.Service{services[0], services[2]}, snapshot.Services(trainRed))
+	assert.Nil(t, snapshot.Services(trainGreen))
+	assert.Equal(t, []string{trainWithoutColour, trainGreen, trainRed, "BLUE"}, snapshot.Colors())
+
+	snapshot.Services(trainRed)[0].Stops[0] = "Z"
+	assert.Equal(t, services[0], snapshot.Services(trainRed)[0])
+}
+
+func Test_GivenAnInvalidService_ReturnError(t *testing.T) {
+	invalid := []dto.Service{
+		{Stops: []string{stationA, stationB}},
+		{Color: trainRed, Stops: []string{stationA}},
+		{Color: trainRed, Stops: []string{stationA, "Z"}},
+		{Color: trainRed, Stops: []string{stationA, stationB, stationA}},
+	}
+
+	for _, service := range invalid {
+		snapshot, err := NewSnapshot(dto.Network{Stations: getStations(), Services: []dto.Service{service}}, time.Now())
+
+		assert.Nil(t, snapshot)
+		assert.NotNil(t, err)
+		assert.True(t, strings.HasPrefix(err.Error(), e.ErrorInvalidNetwork))
+	}
+}
+
+func Test_GivenServiceWindows_ReturnWhetherTheColorRunsItsPattern(t *testing.T) {
+	colors := []dto.Color{
+		{Name: trainGreen, Windows: []dto.Window{{From: "07:00", To: "09:30"}, {From: "18:00", To: "20:00"}}},
+		{Name: trainRed, Windows: []dto.Window{{From: "22:00", To: "01:00"}}},
+	}
+
+	snapshot, err := NewSnapshot(dto.Network{Stations: getStations(), Colors: colors}, time.Now())
+	everyDay := func(string) bool { return true }
+
+	assert.Nil(t, err)
+	assert.True(t, snapshot.RunsAt(trainGreen, 7*time.Hour, everyDay))
+	assert.True(t, snapshot.RunsAt(trainGreen, 19*time.Hour, everyDay))
+	assert.False(t, snapshot.RunsAt(trainGreen, 9*time.Hour+30*time.Minute, everyDay))
+	assert.False(t, snapshot.RunsAt(trainGreen, 12*time.Hour, everyDay))
+	assert.True(t, snapshot.RunsAt(trainRed, 23*time.Hour, everyDay))
+	assert.True(t, snapshot.RunsAt(trainRed, 30*time.Minute, everyDay))
+	assert.False(t, snapshot.RunsAt(trainRed, 12*time.Hour, everyDay))
+	assert.True(t, snapshot.RunsAt(trainWithoutColour, 12*time.Hour, everyDay))
+}
+
+func Test_GivenCalendars_ReturnThoseNamedAndApplyOnlyTheirWindows(t *testing.T) {
+	colors := []dto.Color{
+		{Name: trainGreen, Calendar: "weekdays", Windows: []dto.Window{{From: "07:00", To: "09:00", Calendar: "school"}, {From: "18:00", To: "20:00"}}},
+	}
+	services := []dto.Service{{Color: trainRed, Stops: []string{stationA, stationB}, Calendar: "weekends"}}
+
+	snapshot, err := NewSnapshot(dto.Network{Stations: getStations(), Colors: colors, Services: services}, time.Now())
+	notAtSchool := func(calendar string) bool { return calendar != "school" }
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"school", "weekdays", "weekends"}, snapshot.Calendars())
+	assert.Equal(t, "weekdays", snapshot.ColorCalendar(trainGreen))
+	assert.Equal(t, "", snapshot.ColorCalendar(trainRed))
+	assert.Equal(t, "weekends", snapshot.Services(trainRed)[0].Calendar)
+	assert.False(t, snapshot.RunsAt(trainGreen, 8*time.Hour, notAtSchool))
+	assert.True(t, snapshot.RunsAt(trainGreen, 19*time.Hour, notAtSchool))
+}
+
+func Test_GivenHeadways_ReturnTheOneOfTheDepartureTime(t *testing.T) {
+	colors := []dto.Color{
+		{Name: trainGreen, Headways: []dto.Headway{{From: "07:00", To: "09:00", Minutes: 4, Calendar: "weekdays"}, {Minutes: 10}}},
+	}
+	timing := &dto.Timing{RunMinutes: 1.5, StopMinutes: 0.5}
+
+	snapshot, err := NewSnapshot(dto.Network{Stations: getStations(), Colors: colors, Timing: timing}, time.Now())
+	everyDay := func(string) bool { return true }
+	weekend := func(calendar string) bool { return calendar != "weekdays" }
+
+	assert.Nil(t, err)
+	assert.Equal(t, *timing, snapshot.Timing())
+	assertHeadway(t, 4, true)(snapshot.Headway(trainGreen, 8*time.Hour, everyDay))
+	assertHeadway(t, 10, true)(snapshot.Headway(trainGreen, 8*time.Hour, weekend))
+	assertHeadway(t, 10, true)(snapshot.Headway(trainGreen, 23*time.Hour, everyDay))
+	assertHeadway(t, 0, false)(snapshot.Headway(trainRed, 8*time.Hour, everyDay))
+	assert.Equal(t, []string{"weekdays"}, snapshot.Calendars())
+
+	defaults, _ := NewSnapshot(dto.Network{Stations: getStations()}, time.Now())
+	assert.Equal(t, dto.Timing{RunMinutes: DefaultRunMinutes, StopMinutes: DefaultStopMinutes}, defaults.Timing())
+}
+
+func Test_GivenAnInvalidHeadwayOrTiming_ReturnError(t *testing.T) {
+	invalid := []dto.Network{
+		{Stations: getStations(), Colors: []dto.Color{{Name: trainGreen, Headways: []dto.Headway{{Minutes: 0}}}}},
+		{Stations: getStations(), Colors: []dto.Color{{Name: trainGreen, Headways: []dto.Headway{{From: "07:00", Minutes: 5}}}}},
+		{Stations: getStations(), Timing: &dto.Timing{RunMinutes: 0, StopMinutes: 1}},
+		{Stations: getStations(), Timing: &dto.Timing{RunMinutes: 2, StopMinutes: -1}},
+	}
+
+	for _, network := range invalid {
+		snapshot, err := NewSnapshot(network, time.Now())
+
+		assert.Nil(t, snapshot)
+		assert.NotNil(t, err)
+		assert.True(t, strings.HasPrefix(err.Error(), e.ErrorInvalidNetwork))
+	}
+}
+
+func assertHeadway(t *testing.T, expectedMinutes float64, expectedOK bool) func(float64, bool) {
+	return func(minutes float64, ok bool) {
+		assert.Equal(t, expectedMinutes, minutes)
+		assert.Equal(t, expectedOK, ok)
+	}
+}
+
+func Test_GivenAnInvalidServiceWindow_ReturnError(t *testing.T) {
+	for _, window := range []dto.Window{{From: "7", To: "09:00"}, {From: "07:00", To: "24:00"}, {From: "07:00", To: "07:00"}} {
+		colors := []dto.Color{{Name: trainGreen, Windows: []dto.Window{window}}}
+
+		snapshot, err := NewSnapshot(dto.Network{Stations: getStations(), Colors: colors}, time.Now())
+
+		assert.Nil(t, snapshot)
+		assert.NotNil(t, err)
+		assert.True(t, strings.HasPrefix(err.Error(), e.ErrorInvalidNetwork))
+	}
+}
+
+func Test_GivenLocalizedNames_LabelOptionsInTheLanguageAndAcceptEveryName(t *testing.T) {
+	stations := getStations()
+	stations[0].Names = map[string]string{"es": "Plaza A"}
+	snapshot, err := NewSnapshot(dto.Network{
+		Stations: stations,
+		Colors:   []dto.Color{{Name: trainGreen, Names: map[string]string{"en": trainGreen, "es": "VERDE"}}},
+	}, time.Now())
+
+	assert.Nil(t, err)
+	assert.Equal(t, dto.Option{Value: trainGreen, Label: "VERDE", Aliases: []string{trainGreen}}, snapshot.ColorOption(trainGreen, i18n.Spanish))
+	assert.Equal(t, dto.Option{Value: trainGreen, Label: trainGreen, Aliases: []string{"VERDE"}}, snapshot.ColorOption(trainGreen, i18n.English))
+	assert.Equal(t, dto.Option{Value: trainRed, Label: trainRed}, snapshot.ColorOption(trainRed, i18n.Spanish))
+	assert.Equal(t, dto.Option{Value: stationA, Label: "Plaza A", Aliases: []string{stationA}}, snapshot.StationOptions(i18n.Spanish)[0])
+	assert.Equal(t, dto.Option{Value: stationA, Label: stationA, Aliases: []string{"Plaza A"}}, snapshot.StationOptions(i18n.English)[0])
+	assert.Len(t, snapshot.StationOptions(i18n.English), 9)
+}
+
+func Test_GivenStationCodesAndAliases_AcceptThemAsAliases(t *testing.T) {
+	stations := getStations()
+	stations[0].Code = "PA"
+	stations[0].Aliases = []string{"Plaza"}
+	snapshot, err := NewSnapshot(dto.Network{Stations: stations}, time.Now())
+
+	assert.Nil(t, err)
+	assert.Equal(t, dto.Option{Value: stationA, Label: stationA, Aliases: []string{"PA", "Plaza"}}, snapshot.StationOptions(i18n.English)[0])
+
+	stations[1].Code = "PA"
+	_, err = NewSnapshot(dto.Network{Stations: stations}, time.Now())
+
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,185 @@
+package network
+
+import (
+	"buda-challenge/logger"
+	"buda-challenge/metrics"
+	"buda-challenge/reader"
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Store holds the current network snapshot and swaps it atomically when the
+// network file changes. Queries should call Current once and keep using the
+// returned snapshot until they finish.
+type Store struct {
+	Reader   reader.Reader
+	FilePath string
+	Logger   *logger.Logger
+	// Validate, when set, checks every new snapshot against what the network
+	// depends on outside its file. A snapshot it rejects is kept out as an
+	// invalid file would be.
+	Validate func(snapshot *Snapshot) error
+
+	current   atomic.Value
+	mutex     sync.Mutex
+	loaded    stamp
+	failed    stamp
+	listeners []func(snapshot *Snapshot)
+	metrics   *metrics.Planner
+}
+
+// stamp identifies a version of the network file by its modification time
+// and size.
+type stamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func stampOf(info os.FileInfo) stamp {
+	return stamp{modTime: info.ModTime(), size: info.Size()}
+}
+
+func (v stamp) is(other stamp) bool {
+	return v.modTime.Equal(other.modTime) && v.size == other.size
+}
+
+func NewStore(reader reader.Reader, filePath string) *Store {
+	return &Store{
+		Reader:   reader,
+		FilePath: filePath,
+	}
+}
+
+// Current returns the snapshot being served, or nil before the first
+// successful load.
+func (s *Store) Current() *Snapshot {
+	snapshot, _ := s.current.Load().(*Snapshot)
+	return snapshot
+}
+
+// OnReload registers a function called with every new snapshot, after it
+// has been swapped in.
+func (s *Store) OnReload(listener func(snapshot *Snapshot)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.listeners = append(s.listeners, listener)
+}
+
+// Observe records in planner the time every reload takes, failed ones
+// included, as the load stage.
+func (s *Store) Observe(planner *metrics.Planner) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.metrics = planner
+}
+
+// Reload reads and validates the network file and swaps it in. When the new
+// file cannot be used the current snapshot is kept and the error returned;
+// the failed version is remembered so that Watch does not retry it until the
+// file changes again.
+func (s *Store) Reload(ctx context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	defer s.metrics.ObserveStage(metrics.StageLoad, time.Now())
+
+	info, err := os.Stat(s.FilePath)
+	if err != nil {
+		s.keep(ctx, err)
+		return err
+	}
+	file := stampOf(info)
+
+	network, err := s.Reader.ReadFile(ctx, s.FilePath)
+	if err != nil {
+		s.failed = file
+		s.keep(ctx, err)
+		return err
+	}
+
+	snapshot, err := NewSnapshot(network, time.Now())
+	if err == nil && s.Validate != nil {
+		err = s.Validate(snapshot)
+	}
+	if err != nil {
+		s.failed = file
+		s.keep(ctx, err)
+		return err
+	}
+
+	s.loaded, s.failed = file, stamp{}
+	if current := s.Current(); current != nil && current.Version() == snapshot.Version() {
+		return nil
+	}
+
+	s.current.Store(snapshot)
+	s.Logger.Info(ctx, "serving network", logger.Fields{
+		"file":            s.FilePath,
+		"network_version": snapshot.Version(),
+		"stations":        len(snapshot.StationNames()),
+	})
+	for _, listener := range s.listeners {
+		listener(snapshot)
+	}
+
+	return nil
+}
+
+// Watch reloads the network when the file changes, checking every interval,
+// and whenever the process receives SIGHUP. A zero interval disables polling.
+// It returns when ctx is done.
+func (s *Store) Watch(ctx context.Context, interval time.Duration) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			_ = s.Reload(ctx)
+		case <-tick:
+			if s.changed() {
+				_ = s.Reload(ctx)
+			}
+		}
+	}
+}
+
+func (s *Store) changed() bool {
+	info, err := os.Stat(s.FilePath)
+	if err != nil {
+		return false
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	file := stampOf(info)
+	return !file.is(s.loaded) && !file.is(s.failed)
+}
+
+// keep logs why the current snapshot is still being served.
+func (s *Store) keep(ctx context.Context, err error) {
+	version := ""
+	if current := s.Current(); current != nil {
+		version = current.Version()
+	}
+
+	s.Logger.Warn(ctx, "keeping current network", logger.Fields{
+		"file":            s.FilePath,
+		"network_version": version,
+		"error":           err,
+	})
+}
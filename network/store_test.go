@@ -0,0 +1,188 @@
+package network
+
+import (
+	"buda-challenge/dto"
+	e "buda-challenge/error"
+	"buda-challenge/metrics"
+	"buda-challenge/reader"
+	"buda-challenge/validator"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	stationA           = "A"
+	stationB           = "B"
+	stationC           = "C"
+	stationD           = "D"
+	stationE           = "E"
+	stationF           = "F"
+	stationG           = "G"
+	stationH           = "H"
+	stationI           = "I"
+	trainRed           = "RED"
+	trainGreen         = "GREEN"
+	trainWithoutColour = "WITHOUT COLOR"
+)
+
+func Test_WhenNetworkFileIsValid_StoreServesIt(t *testing.T) {
+	store, _ := newStore(t, getStations())
+
+	err := store.Reload(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, getStations(), store.Current().Stations())
+}
+
+func Test_WhenNetworkFileBecomesInvalid_StoreKeepsServingThePreviousSnapshot(t *testing.T) {
+	store, path := newStore(t, getStations())
+	_ = store.Reload(context.Background())
+	previous := store.Current()
+
+	assert.Nil(t, ioutil.WriteFile(path, []byte("[{\"name\": \"A\"}"), 0644))
+	err := store.Reload(context.Background())
+
+	assert.NotNil(t, err)
+	assert.Same(t, previous, store.Current())
+}
+
+func Test_WhenNetworkFileBecomesInvalid_StoreRemembersTheLoadedAndTheFailedVersions(t *testing.T) {
+	store, path := newStore(t, getStations())
+	_ = store.Reload(context.Background())
+	loaded := store.loaded
+
+	assert.Nil(t, ioutil.WriteFile(path, []byte("[{\"name\": \"A\"}"), 0644))
+	_ = store.Reload(context.Background())
+
+	assert.True(t, store.loaded.is(loaded))
+	assert.False(t, store.failed.is(loaded))
+	assert.False(t, store.changed())
+
+	writeNetwork(t, path, getStations()[:2])
+
+	assert.True(t, store.changed())
+}
+
+func Test_WhenValidateRejectsTheNetwork_StoreKeepsServingThePreviousSnapshot(t *testing.T) {
+	store, path := newStore(t, getStations())
+	_ = store.Reload(context.Background())
+	previous := store.Current()
+
+	store.Validate = func(snapshot *Snapshot) error {
+		return errors.New(e.ErrorInvalidCalendar)
+	}
+	writeNetwork(t, path, getStations()[:2])
+	err := store.Reload(context.Background())
+
+	assert.NotNil(t, err)
+	assert.Equal(t, e.ErrorInvalidCalendar, err.Error())
+	assert.Same(t, previous, store.Current())
+}
+
+func Test_WhenNetworkFileChanges_StoreSwapsSnapshotAndNotifiesListeners(t *testing.T) {
+	store, path := newStore(t, getStations())
+	_ = store.Reload(context.Background())
+	inFlight := store.Current()
+
+	var notified *Snapshot
+	store.OnReload(func(snapshot *Snapshot) {
+		notified = snapshot
+	})
+
+	changed := getStations()[:2]
+	writeNetwork(t, path, changed)
+	err := store.Reload(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, changed, store.Current().Stations())
+	assert.Same(t, store.Current(), notified)
+	assert.Equal(t, getStations(), inFlight.Stations())
+}
+
+func Test_WhenWatchingAndTheFileChanges_StoreReloadsIt(t *testing.T) {
+	store, path := newStore(t, getStations())
+	_ = store.Reload(context.Background())
+
+	reloaded := make(chan *Snapshot, 1)
+	store.OnReload(func(snapshot *Snapshot) {
+		reloaded <- snapshot
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go store.Watch(ctx, time.Millisecond)
+
+	changed := getStations()[:3]
+	writeNetwork(t, path, changed)
+	assert.Nil(t, os.Chtimes(path, time.Now().Add(time.Minute), time.Now().Add(time.Minute)))
+
+	select {
+	case snapshot := <-reloaded:
+		assert.Equal(t, changed, snapshot.Stations())
+	case <-time.After(5 * time.Second):
+		t.Fatal("network was not reloaded")
+	}
+}
+
+func Test_WhenStoreIsObserved_RecordTheTimeOfEveryReload(t *testing.T) {
+	store, path := newStore(t, getStations())
+	registry := metrics.NewRegistry()
+	store.Observe(metrics.NewPlanner(registry))
+
+	assert.Nil(t, store.Reload(context.Background()))
+	assert.Nil(t, ioutil.WriteFile(path, []byte("{"), 0644))
+	assert.NotNil(t, store.Reload(context.Background()))
+
+	var output bytes.Buffer
+	_, err := registry.WriteTo(&output)
+	assert.Nil(t, err)
+	assert.Contains(t, output.String(), `metro_stage_duration_seconds_count{stage="load"} 2`)
+}
+
+func newStore(t *testing.T, stations []dto.Station) (*Store, string) {
+	dir, err := ioutil.TempDir("", "network")
+	assert.Nil(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "train_network.json")
+	writeNetwork(t, path, stations)
+
+	store := NewStore(reader.ReaderImpl{Validator: validator.ValidatorImpl{}}, path)
+
+	return store, path
+}
+
+func writeNetwork(t *testing.T, path string, stations []dto.Station) {
+	content, err := json.Marshal(stations)
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(path, content, 0644))
+}
+
+func getStations() []dto.Station {
+	stationA := dto.Station{Name: stationA, Forks: nil, TrainColor: trainWithoutColour}
+	stationB := dto.Station{Name: stationB, Forks: nil, TrainColor: trainWithoutColour}
+	stationC := dto.Station{Name: stationC, Forks: [][]dto.Station{
+		{
+			{Name: stationD, Forks: nil, TrainColor: trainWithoutColour},
+			{Name: stationE, Forks: nil, TrainColor: trainWithoutColour},
+		},
+		{
+			{Name: stationG, Forks: nil, TrainColor: trainGreen},
+			{Name: stationH, Forks: nil, TrainColor: trainRed},
+			{Name: stationI, Forks: nil, TrainColor: trainGreen},
+		},
+	},
+		TrainColor: trainWithoutColour,
+	}
+	stationF := dto.Station{Name: stationF, Forks: nil, TrainColor: trainWithoutColour}
+
+	return []dto.Station{stationA, stationB, stationC, stationF}
+}
@@ -2,44 +2,77 @@ package processor
 
 import (
 	"buda-challenge/dto"
+	e "buda-challenge/error"
+	"buda-challenge/logger"
 	"buda-challenge/validator"
+	"context"
+	"fmt"
 	"math"
-	"sort"
+	"strings"
 )
 
+// Processor finds routes through a train network. A route lists stations in
+// the order a train runs through them, so it is travelled in that order only;
+// the opposite direction is a route of its own. Every method returns new
+// slices and leaves its arguments untouched, so routes can be shared between
+// callers and goroutines without copying them first.
 type Processor interface {
-	GetStations(stations []dto.Station, trainColor string) ([]string, [][]string)
-	GetRoutes(stationsWithoutForks []string, forks [][]string) [][]string
-	SortRoutes(routes [][]string, lastStation string)
-	GetRoute(routes []string, initialStation, lastStation string) []string
-	GetShortestRoute(routes [][]string, initialStation, lastStation string) []string
+	GetStations(ctx context.Context, stations []dto.Station, trainColor string) ([]string, [][]string, error)
+	GetRoutes(ctx context.Context, stations []dto.Station, trainColor string) ([][]string, error)
+	GetTransferRoutes(ctx context.Context, stations []dto.Station, trainColor string) ([][]string, error)
+	GetCircleRoutes(ctx context.Context, stations []dto.Station, trainColor string) ([][]string, error)
+	GetServiceRoutes(ctx context.Context, physicalRoutes [][]string, services []dto.Service) ([][]string, error)
+	GetRoute(ctx context.Context, routes []string, initialStation, lastStation string) ([]string, error)
+	GetShortestRoute(ctx context.Context, routes [][]string, initialStation, lastStation string) ([]string, error)
+	GetShortestRouteAlong(ctx context.Context, routes, physicalRoutes [][]string, initialStation, lastStation string) ([]string, error)
+	GetPath(ctx context.Context, physicalRoutes [][]string, route []string) ([]dto.PathStation, error)
+	GetTowards(ctx context.Context, physicalRoutes [][]string, route []string) (string, error)
+	GetExpectation(ctx context.Context, trainColor string, headway float64, timing dto.Timing, result dto.Result) (dto.Expectation, error)
 }
 
 type ProcessorImpl struct {
 	Validator validator.Validator
+	Logger    *logger.Logger
 }
 
-func(p ProcessorImpl) GetStations(stations []dto.Station, trainColor string) ([]string, [][]string) {
+// GetStations returns the stations of the line where a trainColor train
+// stops in either direction, and for every fork, nested ones included, the
+// stations of the branch where it does.
+func(p ProcessorImpl) GetStations(ctx context.Context, stations []dto.Station, trainColor string) ([]string, [][]string, error) {
 	var stationsWithoutForks []string
 	var forks [][]string
 
 	for _, station := range stations {
-		if validateTrainColor(trainColor, station) {
-			for _, v := range station.Forks {
-				forks = append(forks, GetForkNames(v, trainColor))
-			}
+		if err := e.FromContext(ctx); err != nil {
+			return nil, nil, err
+		}
+		if stopsEitherWay(trainColor, station) {
 			stationsWithoutForks = append(stationsWithoutForks, station.Name)
 		}
+		forks = appendForks(forks, station, trainColor)
 	}
 
-	return stationsWithoutForks, forks
+	p.Logger.Debug(ctx, "stations filtered", logger.Fields{"train_color": trainColor, "stations": stationsWithoutForks, "forks": forks})
+	return stationsWithoutForks, forks, nil
+}
+
+// appendForks appends to forks the stops of every branch leaving station,
+// each followed by the branches nested in it.
+func appendForks(forks [][]string, station dto.Station, trainColor string) [][]string {
+	for _, fork := range station.Forks {
+		forks = append(forks, GetForkNames(fork, trainColor))
+		for _, nested := range fork {
+			forks = appendForks(forks, nested, trainColor)
+		}
+	}
+	return forks
 }
 
 func GetForkNames(forks []dto.Station, trainColor string) []string {
 	var forksNames []string
 
 	for _, fork := range forks {
-		if validateTrainColor(trainColor, fork) {
+		if stopsEitherWay(trainColor, fork) {
 			forksNames = append(forksNames, fork.Name)
 		}
 	}
@@ -51,78 +84,380 @@ func validateTrainColor(trainColor string, fork dto.Station) bool {
 	return trainColor == "WITHOUT COLOR" || fork.TrainColor == "WITHOUT COLOR" || fork.TrainColor == trainColor
 }
 
-func(p ProcessorImpl) GetRoutes(stationsWithoutForks []string, forks [][]string) [][]string {
-	var routes [][]string
+// validateTrainColorTowards is validateTrainColor for a train travelling in
+// direction, where the station may declare another train color.
+func validateTrainColorTowards(trainColor string, station dto.Station, direction string) bool {
+	if color, ok := station.TrainColors[direction]; ok {
+		station.TrainColor = color
+	}
+	return validateTrainColor(trainColor, station)
+}
+
+func stopsEitherWay(trainColor string, station dto.Station) bool {
+	return validateTrainColorTowards(trainColor, station, dto.DirectionForward) || validateTrainColorTowards(trainColor, station, dto.DirectionBackward)
+}
+
+func opposite(direction string) string {
+	if direction == dto.DirectionForward {
+		return dto.DirectionBackward
+	}
+	return dto.DirectionForward
+}
+
+// GetRoutes returns every route a trainColor train can follow through the
+// network, listing the stations where it stops in the order of the line. The
+// order comes from the network itself: a station with forks continues
+// through one of its branches, which rejoin the line at the next station, so
+// there is one route for every combination of branches. Branches may fork
+// again; a branch ending in forks rejoins the line through all of them.
+// Routes in the order of stations come first, followed by the same routes
+// the other way round, each stopping where trainColor stops in its
+// direction. The routes of every color line up: the i-th route of a color
+// runs along the i-th route of any other.
+func(p ProcessorImpl) GetRoutes(ctx context.Context, stations []dto.Station, trainColor string) ([][]string, error) {
+	if len(stations) == 0 {
+		return nil, nil
+	}
+
+	forward, err := follow(ctx, [][]string{nil}, stations, trainColor, dto.DirectionForward)
+	if err != nil {
+		return nil, err
+	}
+	backward, err := follow(ctx, [][]string{nil}, stations, trainColor, dto.DirectionBackward)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := extend(forward, nil)
+	for _, route := range backward {
+		routes = append(routes, reverse(route))
+	}
+	return routes, nil
+}
+
+// follow continues routes through stations, branching at every fork, with
+// the stops of a train travelling in direction.
+func follow(ctx context.Context, routes [][]string, stations []dto.Station, trainColor, direction string) ([][]string, error) {
+	for _, station := range stations {
+		if err := e.FromContext(ctx); err != nil {
+			return nil, err
+		}
+		if validateTrainColorTowards(trainColor, station, direction) {
+			routes = extend(routes, []string{station.Name})
+		}
+		if len(station.Forks) == 0 {
+			continue
+		}
+
+		var branched [][]string
+		for _, fork := range station.Forks {
+			forkRoutes, err := follow(ctx, routes, fork, trainColor, direction)
+			if err != nil {
+				return nil, err
+			}
+			branched = append(branched, forkRoutes...)
+		}
+		routes = branched
+	}
+
+	return routes, nil
+}
+
+// GetTransferRoutes returns the routes between stations on different
+// branches of a fork, which no route of GetRoutes joins. Such a route runs
+// along one branch back to the station where the branches split and then
+// along the other one, so branches may end at terminals of their own. When
+// the branches rejoin, there is also a route through the station where they
+// do. The train changes direction at that station, so it must stop there in
+// both directions; otherwise the route is left empty, which keeps the routes
+// of every color lined up as in GetRoutes. The routes come first one way and
+// then the other way round.
+func(p ProcessorImpl) GetTransferRoutes(ctx context.Context, stations []dto.Station, trainColor string) ([][]string, error) {
+	lines, err := follow(ctx, [][]string{nil}, stations, "WITHOUT COLOR", dto.DirectionForward)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := map[string]dto.Station{}
+	dto.Walk(stations, func(station dto.Station) {
+		byName[station.Name] = station
+	})
+
+	var forward, backward [][]string
+	seen := map[string]bool{}
+	for i := range lines {
+		for j := i + 1; j < len(lines); j++ {
+			if err := e.FromContext(ctx); err != nil {
+				return nil, err
+			}
+			for _, t := range transfers(lines[i], lines[j]) {
+				key := strings.Join(append(append(clone(t.before), t.junction), t.after...), "\x00")
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+
+				forward = append(forward, t.route(byName, trainColor))
+				backward = append(backward, t.reversed().route(byName, trainColor))
+			}
+		}
+	}
+
+	return append(forward, backward...), nil
+}
+
+// GetCircleRoutes returns the routes of a circle line, where the last of
+// stations connects back to the first: from every station once around the
+// loop, forward first and then backward. Each route stops where trainColor
+// stops in its direction, so a color may serve the loop differently each way.
+func(p ProcessorImpl) GetCircleRoutes(ctx context.Context, stations []dto.Station, trainColor string) ([][]string, error) {
+	var forward, backward [][]string
+	for start := range stations {
+		if err := e.FromContext(ctx); err != nil {
+			return nil, err
+		}
+
+		var forwardRoute, backwardRoute []string
+		for i := range stations {
+			ahead := stations[(start+i)%len(stations)]
+			if validateTrainColorTowards(trainColor, ahead, dto.DirectionForward) {
+				forwardRoute = append(forwardRoute, ahead.Name)
+			}
+			behind := stations[(start-i+len(stations))%len(stations)]
+			if validateTrainColorTowards(trainColor, behind, dto.DirectionBackward) {
+				backwardRoute = append(backwardRoute, behind.Name)
+			}
+		}
+		forward = append(forward, forwardRoute)
+		backward = append(backward, backwardRoute)
+	}
+
+	return append(forward, backward...), nil
+}
+
+// GetServiceRoutes returns the routes of services: the stops of every service
+// in order and then, since a service turns back at its ends, the same stops
+// reversed. A train of a service only runs between its ends, so no route
+// goes beyond them. It fails if a service does not run along any of the
+// physicalRoutes.
+func(p ProcessorImpl) GetServiceRoutes(ctx context.Context, physicalRoutes [][]string, services []dto.Service) ([][]string, error) {
+	var forward, backward [][]string
+	for _, service := range services {
+		if err := e.FromContext(ctx); err != nil {
+			return nil, err
+		}
+		if !runsAlongAny(physicalRoutes, service.Stops) {
+			return nil, fmt.Errorf("%s: %s service does not run along the line through %s", e.ErrorInvalidNetwork, service.Color, strings.Join(service.Stops, ", "))
+		}
+
+		forward = append(forward, clone(service.Stops))
+		backward = append(backward, reverse(service.Stops))
+	}
+
+	return append(forward, backward...), nil
+}
+
+func runsAlongAny(physicalRoutes [][]string, stops []string) bool {
+	for _, physicalRoute := range physicalRoutes {
+		if VisitsInOrder(physicalRoute, stops) {
+			return true
+		}
+	}
+	return false
+}
+
+// transfer is a route between two branches: along before in direction,
+// then along after in the opposite direction once the train turns back at
+// junction.
+type transfer struct {
+	before    []string
+	junction  string
+	after     []string
+	direction string
+}
+
+// route returns the stops of a trainColor train following t, or nil if it
+// does not stop at the junction both ways.
+func (t transfer) route(byName map[string]dto.Station, trainColor string) []string {
+	junction := byName[t.junction]
+	if !validateTrainColorTowards(trainColor, junction, t.direction) || !validateTrainColorTowards(trainColor, junction, opposite(t.direction)) {
+		return nil
+	}
+
 	var route []string
+	for _, name := range t.before {
+		if validateTrainColorTowards(trainColor, byName[name], t.direction) {
+			route = append(route, name)
+		}
+	}
+	route = append(route, t.junction)
+	for _, name := range t.after {
+		if validateTrainColorTowards(trainColor, byName[name], opposite(t.direction)) {
+			route = append(route, name)
+		}
+	}
+	return route
+}
+
+// reversed returns t the other way round.
+func (t transfer) reversed() transfer {
+	return transfer{
+		before:    reverse(t.after),
+		junction:  t.junction,
+		after:     reverse(t.before),
+		direction: t.direction,
+	}
+}
+
+// transfers returns the routes between the branches where lines a and b part:
+// back through the station where they split and, if they rejoin, through the
+// station where they do.
+func transfers(a, b []string) []transfer {
+	split := 0
+	for split < len(a) && split < len(b) && a[split] == b[split] {
+		split++
+	}
+	if split == 0 || split == len(a) || split == len(b) {
+		return nil
+	}
 
-	for _, v := range forks {
-		route = append(stationsWithoutForks, v...)
-		routes = append(routes, route)
+	branchA, branchB := a[split:], b[split:]
+	endA, endB := len(branchA), len(branchB)
+	for i, station := range branchA {
+		if contains(branchB, station) {
+			endA, endB = i, GetPosition(branchB, station)
+			break
+		}
+	}
+	if endA == 0 || endB == 0 {
+		return nil
 	}
 
-	return routes
+	result := []transfer{{
+		before:    reverse(branchA[:endA]),
+		junction:  a[split-1],
+		after:     clone(branchB[:endB]),
+		direction: dto.DirectionBackward,
+	}}
+	if endA < len(branchA) {
+		result = append(result, transfer{
+			before:    clone(branchA[:endA]),
+			junction:  branchA[endA],
+			after:     reverse(branchB[:endB]),
+			direction: dto.DirectionForward,
+		})
+	}
+
+	return result
 }
 
-func(p ProcessorImpl) SortRoutes(routes [][]string, lastStation string) {
+// extend returns a copy of every route followed by stations.
+func extend(routes [][]string, stations []string) [][]string {
+	extended := make([][]string, 0, len(routes))
 	for _, route := range routes {
-		sortRoute(route, lastStation)
+		next := make([]string, 0, len(route)+len(stations))
+		next = append(append(next, route...), stations...)
+		extended = append(extended, next)
 	}
+	return extended
 }
 
-func sortRoute(route []string, lastStation string) []string {
-	r := sort.SearchStrings(route, lastStation)
-	return append(append(route[:r], route[r+1:]...), lastStation)
-}
+// GetRoute returns the stations of route from initialStation to lastStation,
+// or nil when route runs through lastStation first.
+func(p ProcessorImpl) GetRoute(ctx context.Context, route []string, initialStation string, lastStation string) ([]string, error) {
+	if err := e.FromContext(ctx); err != nil {
+		return nil, err
+	}
 
-func(p ProcessorImpl) GetRoute(route []string, initialStation string, lastStation string) []string {
 	initialStationPosition := GetPosition(route, initialStation)
 	finalStationPosition := GetPosition(route, lastStation)
 
-	if initialStationPosition <= finalStationPosition {
-		return getRoute(route, initialStationPosition, finalStationPosition)
-	} else {
-		return reverse(getRoute(route, finalStationPosition, initialStationPosition))
+	if initialStationPosition > finalStationPosition {
+		return nil, nil
 	}
+	return getRoute(route, initialStationPosition, finalStationPosition), nil
 }
 
 func getRoute(route []string, initial int, final int) []string {
-	return append(route[initial : final+1])
+	return clone(route[initial : final+1])
 }
 
 func reverse(input []string) []string {
-	for i, j := 0, len(input)-1; i < j; i, j = i+1, j-1 {
-		input[i], input[j] = input[j], input[i]
+	reversed := make([]string, len(input))
+	for i, station := range input {
+		reversed[len(input)-1-i] = station
 	}
 
-	return input
+	return reversed
 }
 
-func(p ProcessorImpl) GetShortestRoute(routes [][]string, initialStation, lastStation string) []string {
+func clone(route []string) []string {
+	if route == nil {
+		return nil
+	}
+	return append(make([]string, 0, len(route)), route...)
+}
+
+func(p ProcessorImpl) GetShortestRoute(ctx context.Context, routes [][]string, initialStation, lastStation string) ([]string, error) {
 	var shortestDistance int
 	var shortestRoute []string
 
 	for _, route := range routes {
-		if p.Validator.Validate(lastStation, route) {
+		if err := e.FromContext(ctx); err != nil {
+			return nil, err
+		}
+		if p.Validator.Validate(initialStation, route) && p.Validator.Validate(lastStation, route) {
 			initialStation := GetPosition(route, initialStation)
 			finalStation := GetPosition(route, lastStation)
+			if initialStation > finalStation {
+				continue
+			}
 
 			currentDistance := CalculateDistance(finalStation, initialStation)
-			shortestDistance = GetShorterDistance(shortestDistance, currentDistance)
 
-			if IsShortestRoute(shortestDistance, currentDistance) {
+			if shortestRoute == nil || IsShortestRoute(shortestDistance, currentDistance) {
+				shortestDistance = currentDistance
 				shortestRoute = route
 			}
 		}
 	}
 
-	return shortestRoute
+	p.Logger.Debug(ctx, "shortest route chosen", logger.Fields{"routes": len(routes), "route": shortestRoute})
+	return clone(shortestRoute), nil
 }
 
-func GetShorterDistance(shortestDistance int, distance int) int {
-	if shortestDistance == 0 {
-		shortestDistance = distance
+// GetShortestRouteAlong returns, like GetShortestRoute, a route of routes
+// from initialStation to lastStation, but the one where the train runs
+// through the fewest stations rather than the one where it makes the fewest
+// stops. physicalRoutes[i] is the physical route routes[i] runs along. Going
+// around a circle line one way may make fewer stops over a longer distance.
+func(p ProcessorImpl) GetShortestRouteAlong(ctx context.Context, routes, physicalRoutes [][]string, initialStation, lastStation string) ([]string, error) {
+	var shortestDistance int
+	var shortestRoute []string
+
+	for i, route := range routes {
+		if err := e.FromContext(ctx); err != nil {
+			return nil, err
+		}
+		if i >= len(physicalRoutes) || !p.Validator.Validate(initialStation, route) || !p.Validator.Validate(lastStation, route) {
+			continue
+		}
+		if GetPosition(route, initialStation) > GetPosition(route, lastStation) {
+			continue
+		}
+
+		initial := GetPosition(physicalRoutes[i], initialStation)
+		final := GetPosition(physicalRoutes[i], lastStation)
+		currentDistance := CalculateDistance(final, initial)
+
+		if shortestRoute == nil || IsShortestRoute(shortestDistance, currentDistance) {
+			shortestDistance = currentDistance
+			shortestRoute = route
+		}
 	}
-	return shortestDistance
+
+	p.Logger.Debug(ctx, "shortest route chosen", logger.Fields{"routes": len(routes), "route": shortestRoute})
+	return clone(shortestRoute), nil
 }
 
 func IsShortestRoute(lastDistance int, distance int) bool {
@@ -146,3 +481,165 @@ func GetPosition(stations []string, station string) int {
 	}
 	return position
 }
+
+// GetPath returns every station the train runs through to follow route,
+// taken from the physical route that visits its stations in order along the
+// fewest stations. Stations of route are marked as stops; the rest are passed
+// through.
+func(p ProcessorImpl) GetPath(ctx context.Context, physicalRoutes [][]string, route []string) ([]dto.PathStation, error) {
+	physicalRoute, err := shortestPhysicalRoute(ctx, physicalRoutes, route)
+	if err != nil {
+		return nil, err
+	}
+
+	if physicalRoute != nil {
+		var path []dto.PathStation
+		if len(route) == 0 {
+			return path, nil
+		}
+
+		initial := GetPosition(physicalRoute, route[0])
+		final := GetPosition(physicalRoute, route[len(route)-1])
+		step := 1
+		if initial > final {
+			step = -1
+		}
+
+		stops := map[string]bool{}
+		for _, station := range route {
+			stops[station] = true
+		}
+		for i := initial; ; i += step {
+			path = append(path, dto.PathStation{Name: physicalRoute[i], Stop: stops[physicalRoute[i]]})
+			if i == final {
+				return path, nil
+			}
+		}
+	}
+
+	path := make([]dto.PathStation, 0, len(route))
+	for _, station := range route {
+		path = append(path, dto.PathStation{Name: station, Stop: true})
+	}
+	return path, nil
+}
+
+// GetTowards returns the terminal the train is heading to while following
+// route: the end, in the direction of travel, of the physical route GetPath
+// follows.
+func(p ProcessorImpl) GetTowards(ctx context.Context, physicalRoutes [][]string, route []string) (string, error) {
+	if len(route) == 0 {
+		return "", nil
+	}
+
+	physicalRoute, err := shortestPhysicalRoute(ctx, physicalRoutes, route)
+	if err != nil {
+		return "", err
+	}
+	if physicalRoute == nil {
+		return route[len(route)-1], nil
+	}
+
+	if len(route) > 1 && GetPosition(physicalRoute, route[0]) > GetPosition(physicalRoute, route[len(route)-1]) {
+		return physicalRoute[0], nil
+	}
+	return physicalRoute[len(physicalRoute)-1], nil
+}
+
+// shortestPhysicalRoute returns the first of the physical routes that visit
+// route in order with the fewest stations between its ends, or nil if none
+// does.
+// GetExpectation returns the expected time of result by a trainColor train
+// leaving every headway minutes: half the headway waiting, then the time
+// running between stations and stopping at those between the first and the
+// last.
+func(p ProcessorImpl) GetExpectation(ctx context.Context, trainColor string, headway float64, timing dto.Timing, result dto.Result) (dto.Expectation, error) {
+	if err := e.FromContext(ctx); err != nil {
+		return dto.Expectation{}, err
+	}
+
+	stops := len(result.Stops) - 2
+	if stops < 0 {
+		stops = 0
+	}
+
+	wait := round(headway / 2)
+	ride := round(float64(result.Totals.Distance)*timing.RunMinutes + float64(stops)*timing.StopMinutes)
+	return dto.Expectation{
+		TrainColor:   trainColor,
+		WaitMinutes:  wait,
+		RideMinutes:  ride,
+		TotalMinutes: round(wait + ride),
+	}, nil
+}
+
+// IsFasterExpectation returns whether alternative is expected to take less
+// time than expected.
+func IsFasterExpectation(expected, alternative dto.Expectation) bool {
+	return alternative.TotalMinutes < expected.TotalMinutes
+}
+
+// round rounds minutes to tenths.
+func round(minutes float64) float64 {
+	return math.Round(minutes*10) / 10
+}
+
+func shortestPhysicalRoute(ctx context.Context, physicalRoutes [][]string, route []string) ([]string, error) {
+	var shortestRoute []string
+	var shortestDistance int
+
+	for _, physicalRoute := range physicalRoutes {
+		if err := e.FromContext(ctx); err != nil {
+			return nil, err
+		}
+		if !VisitsInOrder(physicalRoute, route) {
+			continue
+		}
+
+		var distance int
+		if len(route) > 0 {
+			distance = CalculateDistance(GetPosition(physicalRoute, route[len(route)-1]), GetPosition(physicalRoute, route[0]))
+		}
+		if shortestRoute == nil || distance < shortestDistance {
+			shortestRoute, shortestDistance = physicalRoute, distance
+		}
+	}
+
+	return shortestRoute, nil
+}
+
+// VisitsInOrder reports whether physicalRoute contains every station of
+// route, in the same order or in reverse.
+func VisitsInOrder(physicalRoute []string, route []string) bool {
+	direction := 0
+
+	for i, station := range route {
+		if !contains(physicalRoute, station) {
+			return false
+		}
+		if i == 0 {
+			continue
+		}
+
+		step := GetPosition(physicalRoute, station) - GetPosition(physicalRoute, route[i-1])
+		switch {
+		case step == 0:
+			return false
+		case direction == 0:
+			direction = step
+		case (step > 0) != (direction > 0):
+			return false
+		}
+	}
+
+	return true
+}
+
+func contains(stations []string, station string) bool {
+	for _, v := range stations {
+		if v == station {
+			return true
+		}
+	}
+	return false
+}
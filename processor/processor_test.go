@@ -4,7 +4,12 @@ import (
 	"buda-challenge/dto"
 	"buda-challenge/validator"
 	"buda-challenge/configuration"
+	e "buda-challenge/error"
+	"context"
+	"errors"
 	"github.com/stretchr/testify/assert"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -12,68 +17,480 @@ const (
 	lastStation = "F"
 	initialStation = "A"
 	endStation = "F"
+
+	tobalaba = "Tobalaba"
+	losLeones = "Los Leones"
+	pedroDeValdivia = "Pedro de Valdivia"
+	manuelMontt = "Manuel Montt"
+	salvador = "Salvador"
+	irarrazaval = "Irarrázaval"
+	nunoa = "Ñuñoa"
+	baquedano = "Baquedano"
+	universidadDeChile = "Universidad de Chile"
 )
 
 func Test_GivenATrainNetworkAndATrainColorGreen_ReturnValidStationsAndForks(t *testing.T) {
 	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
 
-	stationsWithoutForks, forks := processor.GetStations(getTrainNetwork(), configuration.TrainGreen)
+	stationsWithoutForks, forks, err := processor.GetStations(context.Background(), getTrainNetwork(), configuration.TrainGreen)
 
 	stationsWithoutForksExpected := []string{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationF}
 	forksExpectedForRedTrain := [][]string{{configuration.StationD, configuration.StationE}, {configuration.StationG, configuration.StationI}}
 
 	assert.Equal(t, stationsWithoutForksExpected, stationsWithoutForks)
 	assert.Equal(t, forksExpectedForRedTrain, forks)
+	assert.Nil(t, err)
 }
 
 func Test_GivenATrainNetworkAndATrainColorRed_ReturnValidStationsAndForks(t *testing.T) {
 	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
 
-	stationsWithoutForks, forks := processor.GetStations(getTrainNetwork(), configuration.TrainRed)
+	stationsWithoutForks, forks, err := processor.GetStations(context.Background(), getTrainNetwork(), configuration.TrainRed)
 
 	stationsWithoutForksExpected := []string{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationF}
 	forksExpectedForRedTrain := [][]string{{configuration.StationD, configuration.StationE}, {configuration.StationH}}
 
 	assert.Equal(t, stationsWithoutForksExpected, stationsWithoutForks)
 	assert.Equal(t, forksExpectedForRedTrain, forks)
+	assert.Nil(t, err)
+}
+
+func Test_GivenATrainNetworkAndATrainColorGreen_ReturnARouteForEachBranchInLineOrder(t *testing.T) {
+	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
+
+	routes, err := processor.GetRoutes(context.Background(), getTrainNetwork(), configuration.TrainGreen)
+
+	routesExpected := [][]string{{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationD, configuration.StationE, configuration.StationF}, {configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationG, configuration.StationI, configuration.StationF}}
+
+	assert.Equal(t, andBack(routesExpected), routes)
+	assert.Nil(t, err)
+}
+
+func Test_GivenANetworkWhoseNamesAreNotAlphabetical_ReturnRoutesInLineOrder(t *testing.T) {
+	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
+
+	routes, err := processor.GetRoutes(context.Background(), getNonAlphabeticalTrainNetwork(), configuration.TrainWithoutColour)
+
+	routesExpected := [][]string{
+		{tobalaba, losLeones, pedroDeValdivia, manuelMontt, salvador, baquedano, universidadDeChile},
+		{tobalaba, losLeones, pedroDeValdivia, irarrazaval, nunoa, baquedano, universidadDeChile},
+	}
+
+	assert.Equal(t, andBack(routesExpected), routes)
+	assert.Nil(t, err)
+}
+
+func Test_GivenANetworkWhoseNamesAreNotAlphabeticalAndATrainColor_ReturnOnlyItsStopsInLineOrder(t *testing.T) {
+	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
+
+	routes, err := processor.GetRoutes(context.Background(), getNonAlphabeticalTrainNetwork(), configuration.TrainRed)
+
+	routesExpected := [][]string{
+		{tobalaba, pedroDeValdivia, manuelMontt, salvador, baquedano, universidadDeChile},
+		{tobalaba, pedroDeValdivia, nunoa, baquedano, universidadDeChile},
+	}
+
+	assert.Equal(t, andBack(routesExpected), routes)
+	assert.Nil(t, err)
+}
+
+func Test_GivenForksAtTwoStations_ReturnARouteForEveryCombinationOfBranches(t *testing.T) {
+	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
+
+	stations := []dto.Station{
+		{Name: "Zapadores", TrainColor: configuration.TrainWithoutColour, Forks: [][]dto.Station{
+			{{Name: "Macul", TrainColor: configuration.TrainWithoutColour}},
+			{{Name: "Cerrillos", TrainColor: configuration.TrainWithoutColour}},
+		}},
+		{Name: "Bellavista", TrainColor: configuration.TrainWithoutColour, Forks: [][]dto.Station{
+			{{Name: "Quinta Normal", TrainColor: configuration.TrainWithoutColour}},
+			{{Name: "Estación Central", TrainColor: configuration.TrainWithoutColour}},
+		}},
+		{Name: "Apoquindo", TrainColor: configuration.TrainWithoutColour},
+	}
+
+	routes, err := processor.GetRoutes(context.Background(), stations, configuration.TrainWithoutColour)
+
+	routesExpected := [][]string{
+		{"Zapadores", "Macul", "Bellavista", "Quinta Normal", "Apoquindo"},
+		{"Zapadores", "Cerrillos", "Bellavista", "Quinta Normal", "Apoquindo"},
+		{"Zapadores", "Macul", "Bellavista", "Estación Central", "Apoquindo"},
+		{"Zapadores", "Cerrillos", "Bellavista", "Estación Central", "Apoquindo"},
+	}
+
+	assert.Equal(t, andBack(routesExpected), routes)
+	assert.Nil(t, err)
+}
+
+func Test_GivenABranchThatForksAgain_ReturnARouteThroughEveryNestedBranch(t *testing.T) {
+	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
+
+	routes, err := processor.GetRoutes(context.Background(), getNestedForksTrainNetwork(), configuration.TrainWithoutColour)
+
+	routesExpected := [][]string{
+		{"Vicente Valdés", "Mirador", "Macul", "Rojas Magallanes", "La Florida"},
+		{"Vicente Valdés", "Mirador", "Camino Agrícola", "Rojas Magallanes", "La Florida"},
+		{"Vicente Valdés", "Bellavista", "La Florida"},
+	}
+
+	assert.Equal(t, andBack(routesExpected), routes)
+	assert.Nil(t, err)
+}
+
+func Test_GivenABranchThatForksAgainAndATrainColor_ReturnItsStopsInEveryBranch(t *testing.T) {
+	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
+
+	stationsWithoutForks, forks, err := processor.GetStations(context.Background(), getNestedForksTrainNetwork(), configuration.TrainGreen)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Vicente Valdés", "La Florida"}, stationsWithoutForks)
+	assert.Equal(t, [][]string{{"Mirador", "Rojas Magallanes"}, {"Macul"}, nil, nil}, forks)
 }
 
-func Test_GivenStationsAndForks_ReturnPossibleRoutes(t *testing.T) {
+func Test_GivenBranchesEndingAtTheirOwnTerminals_ReturnTheRouteBetweenThemThroughTheJunction(t *testing.T) {
 	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
 
-	stationsWithoutForks := []string{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationF}
-	forks := [][]string{{configuration.StationD, configuration.StationE}, {configuration.StationG, configuration.StationH, configuration.StationI}}
+	routes, err := processor.GetTransferRoutes(context.Background(), getYShapedTrainNetwork(configuration.TrainWithoutColour), configuration.TrainWithoutColour)
 
-	routes := processor.GetRoutes(stationsWithoutForks, forks)
+	assert.Nil(t, err)
+	assert.Equal(t, andBack([][]string{{"Los Héroes", "Universidad de Chile", baquedano, "Bellas Artes", "Puente Cal y Canto"}}), routes)
+}
+
+func Test_WhenTheTrainColorDoesNotStopAtTheJunction_ReturnEmptyRoutesBetweenBranches(t *testing.T) {
+	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
 
-	routesExpected := [][]string{{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationF, configuration.StationD, configuration.StationE}, {configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationF, configuration.StationG, configuration.StationH, configuration.StationI}}
+	routes, err := processor.GetTransferRoutes(context.Background(), getYShapedTrainNetwork(configuration.TrainRed), configuration.TrainGreen)
 
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{nil, nil}, routes)
+}
+
+func Test_WhenTheTrainColorStopsAtTheJunctionInOneDirectionOnly_ReturnEmptyRoutesBetweenBranches(t *testing.T) {
+	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
+
+	stations := getYShapedTrainNetwork(configuration.TrainWithoutColour)
+	stations[1].TrainColors = map[string]string{dto.DirectionBackward: configuration.TrainRed}
+
+	routes, err := processor.GetTransferRoutes(context.Background(), stations, configuration.TrainGreen)
+
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{nil, nil}, routes)
+}
+
+func Test_GivenAStationServedInOneDirectionOnly_ReturnItAsAStopOnlyOnRoutesInThatDirection(t *testing.T) {
+	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
+
+	stations := []dto.Station{
+		{Name: tobalaba, TrainColor: configuration.TrainWithoutColour},
+		{Name: losLeones, TrainColor: configuration.TrainGreen, TrainColors: map[string]string{dto.DirectionBackward: configuration.TrainRed}},
+		{Name: pedroDeValdivia, TrainColor: configuration.TrainRed, TrainColors: map[string]string{dto.DirectionBackward: configuration.TrainGreen}},
+		{Name: baquedano, TrainColor: configuration.TrainWithoutColour},
+	}
+
+	routes, err := processor.GetRoutes(context.Background(), stations, configuration.TrainGreen)
+	stationsWithoutForks, _, _ := processor.GetStations(context.Background(), stations, configuration.TrainGreen)
+
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{tobalaba, losLeones, baquedano}, {baquedano, pedroDeValdivia, tobalaba}}, routes)
+	assert.Equal(t, []string{tobalaba, losLeones, pedroDeValdivia, baquedano}, stationsWithoutForks)
+}
+
+func Test_GivenBranchesThatRejoin_ReturnRoutesBetweenThemThroughTheSplitAndTheJoin(t *testing.T) {
+	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
+
+	routes, err := processor.GetTransferRoutes(context.Background(), getTrainNetwork(), configuration.TrainGreen)
+
+	routesExpected := [][]string{
+		{configuration.StationE, configuration.StationD, configuration.StationC, configuration.StationG, configuration.StationI},
+		{configuration.StationD, configuration.StationE, configuration.StationF, configuration.StationI, configuration.StationG},
+	}
+
+	assert.Nil(t, err)
+	assert.Equal(t, andBack(routesExpected), routes)
+}
+
+func Test_GivenACircleLine_ReturnARouteAroundTheLoopFromEveryStationInEachDirection(t *testing.T) {
+	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
+
+	stations := []dto.Station{
+		{Name: "Tokyo", TrainColor: configuration.TrainWithoutColour},
+		{Name: "Kanda", TrainColor: configuration.TrainWithoutColour},
+		{Name: "Akihabara", TrainColor: configuration.TrainGreen, TrainColors: map[string]string{dto.DirectionBackward: configuration.TrainRed}},
+		{Name: "Ueno", TrainColor: configuration.TrainWithoutColour},
+	}
+
+	routes, err := processor.GetCircleRoutes(context.Background(), stations, configuration.TrainGreen)
+
+	routesExpected := [][]string{
+		{"Tokyo", "Kanda", "Akihabara", "Ueno"},
+		{"Kanda", "Akihabara", "Ueno", "Tokyo"},
+		{"Akihabara", "Ueno", "Tokyo", "Kanda"},
+		{"Ueno", "Tokyo", "Kanda", "Akihabara"},
+		{"Tokyo", "Ueno", "Kanda"},
+		{"Kanda", "Tokyo", "Ueno"},
+		{"Kanda", "Tokyo", "Ueno"},
+		{"Ueno", "Kanda", "Tokyo"},
+	}
+
+	assert.Nil(t, err)
 	assert.Equal(t, routesExpected, routes)
 }
 
-func Test_GivenRoutes_ReturnsRoutesOrderedAccordingToTheirPosition(t *testing.T) {
+func Test_GivenServices_ReturnTheirStopsInBothDirections(t *testing.T) {
 	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
 
-	routes := [][]string{{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationF, configuration.StationD, configuration.StationE}, {configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationF, configuration.StationG, configuration.StationH, configuration.StationI}}
+	physicalRoutes, _ := processor.GetRoutes(context.Background(), getTrainNetwork(), configuration.TrainWithoutColour)
+	services := []dto.Service{
+		{Color: configuration.TrainRed, Stops: []string{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationD}},
+		{Color: configuration.TrainRed, Stops: []string{configuration.StationF, configuration.StationH, configuration.StationC}},
+	}
 
-	processor.SortRoutes(routes, lastStation)
+	routes, err := processor.GetServiceRoutes(context.Background(), physicalRoutes, services)
 
-	routesExpected := [][]string{{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationD, configuration.StationE, configuration.StationF}, {configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationG, configuration.StationH, configuration.StationI, configuration.StationF}}
+	routesExpected := [][]string{
+		{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationD},
+		{configuration.StationF, configuration.StationH, configuration.StationC},
+		{configuration.StationD, configuration.StationC, configuration.StationB, configuration.StationA},
+		{configuration.StationC, configuration.StationH, configuration.StationF},
+	}
 
+	assert.Nil(t, err)
 	assert.Equal(t, routesExpected, routes)
+
+	routes[0][0] = "Z"
+	assert.Equal(t, configuration.StationA, services[0].Stops[0])
+}
+
+func Test_WhenAServiceDoesNotRunAlongAnyRoute_ReturnError(t *testing.T) {
+	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
+
+	physicalRoutes, _ := processor.GetRoutes(context.Background(), getTrainNetwork(), configuration.TrainWithoutColour)
+	services := []dto.Service{{Color: configuration.TrainRed, Stops: []string{configuration.StationD, configuration.StationH}}}
+
+	routes, err := processor.GetServiceRoutes(context.Background(), physicalRoutes, services)
+
+	assert.Nil(t, routes)
+	assert.NotNil(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), e.ErrorInvalidNetwork))
 }
 
+func Test_GivenRoutesInBothDirections_ReturnTheShortestOneTravelledInItsOrder(t *testing.T) {
+	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
+
+	routes := [][]string{
+		{"Tokyo", "Kanda", "Akihabara", "Ueno", "Ikebukuro"},
+		{"Ueno", "Ikebukuro", "Tokyo", "Kanda", "Akihabara"},
+		{"Tokyo", "Ikebukuro", "Ueno", "Akihabara", "Kanda"},
+	}
+
+	route, err := processor.GetShortestRoute(context.Background(), routes, "Tokyo", "Ikebukuro")
+
+	assert.Nil(t, err)
+	assert.Equal(t, routes[2], route)
+}
+
+func Test_GivenACircleWhoseWayWithFewerStopsIsLonger_ReturnTheShorterWayAroundIt(t *testing.T) {
+	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
+
+	stations := []dto.Station{
+		{Name: "A", TrainColor: configuration.TrainWithoutColour},
+		{Name: "B", TrainColor: configuration.TrainWithoutColour},
+		{Name: "C", TrainColor: configuration.TrainWithoutColour},
+		{Name: "D", TrainColor: configuration.TrainRed},
+		{Name: "E", TrainColor: configuration.TrainRed},
+		{Name: "F", TrainColor: configuration.TrainRed},
+		{Name: "G", TrainColor: configuration.TrainRed},
+		{Name: "H", TrainColor: configuration.TrainRed},
+	}
+	routes, _ := processor.GetCircleRoutes(context.Background(), stations, configuration.TrainGreen)
+	physicalRoutes, _ := processor.GetCircleRoutes(context.Background(), stations, configuration.TrainWithoutColour)
+
+	fewerStops, err := processor.GetShortestRoute(context.Background(), routes, "A", "C")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"B", "A", "C"}, fewerStops)
+
+	shorter, err := processor.GetShortestRouteAlong(context.Background(), routes, physicalRoutes, "A", "C")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"A", "B", "C"}, shorter)
+}
+
+func Test_GivenANetworkWhoseNamesAreNotAlphabetical_ReturnTheRouteBetweenTwoStationsInLineOrder(t *testing.T) {
+	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
+
+	routes, _ := processor.GetRoutes(context.Background(), getNonAlphabeticalTrainNetwork(), configuration.TrainWithoutColour)
+	shortestRoute, err := processor.GetShortestRoute(context.Background(), routes, universidadDeChile, nunoa)
+	route, _ := processor.GetRoute(context.Background(), shortestRoute, universidadDeChile, nunoa)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{universidadDeChile, baquedano, nunoa}, route)
+}
+
+func Test_GivenThreeForks_ReturnRoutesThatDoNotShareStations(t *testing.T) {
+	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
+
+	routes, err := processor.GetRoutes(context.Background(), getThreeForksTrainNetwork(), configuration.TrainWithoutColour)
+	expected := copyRoutes(routes)
+
+	for i := range routes {
+		routes[i][0] = "Modified"
+		routes[i] = append(routes[i][:1], "Appended")
+		routes[i] = routes[i][:cap(routes[i])]
+		for j := range routes[i] {
+			routes[i][j] = "Overwritten"
+		}
+		assert.Equal(t, expected[i+1:], routes[i+1:])
+	}
+
+	assert.Nil(t, err)
+	assert.Len(t, expected, 16)
+}
+
+func Test_WhenARouteIsFound_TheRoutesItCameFromAreNotModified(t *testing.T) {
+	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
+
+	routes, _ := processor.GetRoutes(context.Background(), getThreeForksTrainNetwork(), configuration.TrainWithoutColour)
+	expected := copyRoutes(routes)
+
+	shortestRoute, _ := processor.GetShortestRoute(context.Background(), routes, "Los Dominicos", "Cumming")
+	route, err := processor.GetRoute(context.Background(), shortestRoute, "Los Dominicos", "Cumming")
+	shortestRoute[0] = "Modified"
+	route[0] = "Modified"
+
+	assert.Nil(t, err)
+	assert.Equal(t, expected, routes)
+}
+
+func Test_WhenTheFinalStationComesFirstOnTheRoute_ReturnNoRoute(t *testing.T) {
+	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
+	route := []string{tobalaba, losLeones, pedroDeValdivia, baquedano}
+
+	forwards, err := processor.GetRoute(context.Background(), route, losLeones, baquedano)
+	backwards, _ := processor.GetRoute(context.Background(), route, baquedano, losLeones)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{losLeones, pedroDeValdivia, baquedano}, forwards)
+	assert.Nil(t, backwards)
+	assert.Equal(t, []string{tobalaba, losLeones, pedroDeValdivia, baquedano}, route)
+}
+
+func Test_WhenRoutesAreSearchedConcurrently_EveryCallerGetsItsOwnRoute(t *testing.T) {
+	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
+	stations := getThreeForksTrainNetwork()
+	names := []string{"Los Dominicos", "Manquehue", "Escuela Militar", "Cumming", "Santa Ana", "Plaza Egaña", "Pudahuel"}
+
+	solve := func(routes [][]string, initial, final string) []string {
+		shortestRoute, _ := processor.GetShortestRoute(context.Background(), routes, initial, final)
+		route, _ := processor.GetRoute(context.Background(), shortestRoute, initial, final)
+		return route
+	}
+
+	sharedRoutes, _ := processor.GetRoutes(context.Background(), stations, configuration.TrainWithoutColour)
+	expected := map[[2]string][]string{}
+	for _, initial := range names {
+		for _, final := range names {
+			expected[[2]string{initial, final}] = solve(sharedRoutes, initial, final)
+		}
+	}
+
+	var wg sync.WaitGroup
+	results := make([]map[[2]string][]string, 16)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ownRoutes, _ := processor.GetRoutes(context.Background(), stations, configuration.TrainWithoutColour)
+			results[i] = map[[2]string][]string{}
+			for _, initial := range names {
+				for _, final := range names {
+					routes := sharedRoutes
+					if (i+len(initial)+len(final))%2 == 0 {
+						routes = ownRoutes
+					}
+					route := solve(routes, initial, final)
+					results[i][[2]string{initial, final}] = copyRoutes([][]string{route})[0]
+					for j := range route {
+						route[j] = "Overwritten"
+					}
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		assert.Equal(t, expected, result)
+	}
+}
 
 func Test_GivenPossibleRoutes_ReturnTheShortestRoute(t *testing.T) {
 	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
 
 	routes := [][]string{{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationD, configuration.StationE, configuration.StationF}, {configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationG, configuration.StationH, configuration.StationI, configuration.StationF}}
 
-	route := processor.GetShortestRoute(routes, initialStation, endStation)
+	route, err := processor.GetShortestRoute(context.Background(), routes, initialStation, endStation)
 
 	routeExpected := []string{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationD, configuration.StationE, configuration.StationF}
 
 	assert.Equal(t, routeExpected, route)
+	assert.Nil(t, err)
+}
+
+func Test_WhenContextIsCanceled_ShortestRouteSearchReturnsTimeoutError(t *testing.T) {
+	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	routes := [][]string{{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationD, configuration.StationE, configuration.StationF}}
+
+	route, err := processor.GetShortestRoute(ctx, routes, initialStation, endStation)
+
+	var timeout e.TimeoutError
+	assert.Nil(t, route)
+	assert.True(t, errors.As(err, &timeout))
+}
+
+func Test_GivenARouteThatSkipsStations_ReturnThePhysicalPathWithStopsMarked(t *testing.T) {
+	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
+
+	physicalRoutes := [][]string{{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationD, configuration.StationE, configuration.StationF}, {configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationG, configuration.StationH, configuration.StationI, configuration.StationF}}
+	route := []string{configuration.StationF, configuration.StationI, configuration.StationG, configuration.StationC}
+
+	path, err := processor.GetPath(context.Background(), physicalRoutes, route)
+
+	pathExpected := []dto.PathStation{
+		{Name: configuration.StationF, Stop: true},
+		{Name: configuration.StationI, Stop: true},
+		{Name: configuration.StationH, Stop: false},
+		{Name: configuration.StationG, Stop: true},
+		{Name: configuration.StationC, Stop: true},
+	}
+
+	assert.Equal(t, pathExpected, path)
+	assert.Nil(t, err)
+}
+
+func Test_GivenARoute_ReturnTheTerminalTheTrainIsHeadingTo(t *testing.T) {
+	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
+
+	physicalRoutes := [][]string{{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationD, configuration.StationE, configuration.StationF}, {configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationG, configuration.StationH, configuration.StationI, configuration.StationF}}
+
+	backwards, err := processor.GetTowards(context.Background(), physicalRoutes, []string{configuration.StationI, configuration.StationG, configuration.StationB})
+	forwards, _ := processor.GetTowards(context.Background(), physicalRoutes, []string{configuration.StationB, configuration.StationD})
+
+	assert.Nil(t, err)
+	assert.Equal(t, configuration.StationA, backwards)
+	assert.Equal(t, configuration.StationF, forwards)
+}
+
+func Test_GivenAPhysicalRouteAndStations_ReturnIfItVisitsThemInOrder(t *testing.T) {
+	physicalRoute := []string{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationD}
+
+	assert.True(t, VisitsInOrder(physicalRoute, []string{configuration.StationA, configuration.StationC, configuration.StationD}))
+	assert.True(t, VisitsInOrder(physicalRoute, []string{configuration.StationD, configuration.StationB}))
+	assert.False(t, VisitsInOrder(physicalRoute, []string{configuration.StationA, configuration.StationD, configuration.StationC}))
+	assert.False(t, VisitsInOrder(physicalRoute, []string{configuration.StationA, configuration.StationH}))
 }
 
 func Test_GivenAStationsList_ReturnsOnlyThoseThatCanBeUsedAccordingToTheChosenColor(t *testing.T) {
@@ -99,10 +516,124 @@ func Test_GiveTwoDistances_ReturnIfShorter(t *testing.T) {
 	assert.True(t, isShortestRoute)
 }
 
-func Test_GiveTwoDistances_ReturnTheShortest(t *testing.T) {
-	shortestRoute := GetShorterDistance(3, 2)
+func Test_GivenAHeadwayAndATiming_ReturnTheExpectedTime(t *testing.T) {
+	processor := ProcessorImpl{Validator: validator.ValidatorImpl{}}
+	result := dto.Result{
+		Stops:  []string{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationF},
+		Totals: dto.Totals{Distance: 5},
+	}
+
+	expected, err := processor.GetExpectation(context.Background(), configuration.TrainGreen, 5, dto.Timing{RunMinutes: 2, StopMinutes: 0.75}, result)
+
+	assert.Nil(t, err)
+	assert.Equal(t, dto.Expectation{TrainColor: configuration.TrainGreen, WaitMinutes: 2.5, RideMinutes: 11.5, TotalMinutes: 14}, expected)
+}
+
+func Test_GivenTwoExpectations_ReturnIfTheAlternativeIsFaster(t *testing.T) {
+	expected := dto.Expectation{TotalMinutes: 26}
+
+	assert.True(t, IsFasterExpectation(expected, dto.Expectation{TotalMinutes: 20}))
+	assert.False(t, IsFasterExpectation(expected, dto.Expectation{TotalMinutes: 26}))
+}
+
+// getThreeForksTrainNetwork returns a line that forks in two branches at
+// three different stations.
+func getThreeForksTrainNetwork() []dto.Station {
+	return []dto.Station{
+		{Name: "Los Dominicos", TrainColor: configuration.TrainWithoutColour, Forks: [][]dto.Station{
+			{{Name: "Manquehue", TrainColor: configuration.TrainWithoutColour}},
+			{{Name: "Hernando de Magallanes", TrainColor: configuration.TrainWithoutColour}},
+		}},
+		{Name: "Escuela Militar", TrainColor: configuration.TrainWithoutColour, Forks: [][]dto.Station{
+			{{Name: "Alcántara", TrainColor: configuration.TrainWithoutColour}},
+			{{Name: "Plaza Egaña", TrainColor: configuration.TrainWithoutColour}, {Name: "Cumming", TrainColor: configuration.TrainWithoutColour}},
+		}},
+		{Name: "Santa Ana", TrainColor: configuration.TrainWithoutColour, Forks: [][]dto.Station{
+			{{Name: "Pudahuel", TrainColor: configuration.TrainWithoutColour}},
+			{{Name: "Lo Prado", TrainColor: configuration.TrainWithoutColour}},
+		}},
+		{Name: "San Pablo", TrainColor: configuration.TrainWithoutColour},
+	}
+}
 
-	assert.Equal(t, 3, shortestRoute)
+// andBack returns routes followed by each of them the other way round.
+func andBack(routes [][]string) [][]string {
+	result := copyRoutes(routes)
+	for _, route := range routes {
+		var back []string
+		for i := len(route) - 1; i >= 0; i-- {
+			back = append(back, route[i])
+		}
+		result = append(result, back)
+	}
+	return result
+}
+
+func copyRoutes(routes [][]string) [][]string {
+	copied := make([][]string, 0, len(routes))
+	for _, route := range routes {
+		copied = append(copied, append([]string(nil), route...))
+	}
+	return copied
+}
+
+// getNestedForksTrainNetwork returns a line whose first branch forks again
+// at Mirador; both nested branches rejoin it at Rojas Magallanes.
+func getNestedForksTrainNetwork() []dto.Station {
+	return []dto.Station{
+		{Name: "Vicente Valdés", TrainColor: configuration.TrainWithoutColour, Forks: [][]dto.Station{
+			{
+				{Name: "Mirador", TrainColor: configuration.TrainWithoutColour, Forks: [][]dto.Station{
+					{{Name: "Macul", TrainColor: configuration.TrainGreen}},
+					{{Name: "Camino Agrícola", TrainColor: configuration.TrainRed}},
+				}},
+				{Name: "Rojas Magallanes", TrainColor: configuration.TrainGreen},
+			},
+			{
+				{Name: "Bellavista", TrainColor: configuration.TrainRed},
+			},
+		}},
+		{Name: "La Florida", TrainColor: configuration.TrainWithoutColour},
+	}
+}
+
+// getYShapedTrainNetwork returns a line that splits at Baquedano into two
+// branches ending at their own terminals.
+func getYShapedTrainNetwork(junctionColor string) []dto.Station {
+	return []dto.Station{
+		{Name: tobalaba, TrainColor: configuration.TrainWithoutColour},
+		{Name: baquedano, TrainColor: junctionColor, Forks: [][]dto.Station{
+			{
+				{Name: "Universidad de Chile", TrainColor: configuration.TrainWithoutColour},
+				{Name: "Los Héroes", TrainColor: configuration.TrainWithoutColour},
+			},
+			{
+				{Name: "Bellas Artes", TrainColor: configuration.TrainWithoutColour},
+				{Name: "Puente Cal y Canto", TrainColor: configuration.TrainWithoutColour},
+			},
+		}},
+	}
+}
+
+// getNonAlphabeticalTrainNetwork returns a line whose station names are not
+// in alphabetical order, with a fork after Pedro de Valdivia.
+func getNonAlphabeticalTrainNetwork() []dto.Station {
+	return []dto.Station{
+		{Name: tobalaba, TrainColor: configuration.TrainWithoutColour},
+		{Name: losLeones, TrainColor: configuration.TrainGreen},
+		{Name: pedroDeValdivia, TrainColor: configuration.TrainWithoutColour, Forks: [][]dto.Station{
+			{
+				{Name: manuelMontt, TrainColor: configuration.TrainWithoutColour},
+				{Name: salvador, TrainColor: configuration.TrainRed},
+			},
+			{
+				{Name: irarrazaval, TrainColor: configuration.TrainGreen},
+				{Name: nunoa, TrainColor: configuration.TrainWithoutColour},
+			},
+		}},
+		{Name: baquedano, TrainColor: configuration.TrainWithoutColour},
+		{Name: universidadDeChile, TrainColor: configuration.TrainWithoutColour},
+	}
 }
 
 func getTrainNetwork() []dto.Station {
@@ -0,0 +1,379 @@
+package reader
+
+import (
+	e "buda-challenge/error"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Editor reads lines with editing, history and completion when MakeRaw is
+// set, and plain lines otherwise, e.g. when the input is piped.
+type Editor struct {
+	Input  io.Reader
+	Output io.Writer
+	// History, when set, is browsed with the up and down arrows and gets
+	// every line read.
+	History *History
+	// Complete returns the candidates for the last of words, which is the
+	// word being typed and may be empty.
+	Complete func(words []string) []string
+	// MakeRaw switches the terminal to raw mode and returns the function
+	// restoring it.
+	MakeRaw func() (func() error, error)
+
+	keys  *KeyReader
+	lines *lineReader
+}
+
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyBackspace = 8
+	keyTab       = 9
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyNewLine   = 10
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+// ErrInterrupted is returned by ReadLine when Ctrl+C is pressed.
+var ErrInterrupted = errors.New("interrupted")
+
+// ReadLine prints prompt and returns the line entered, without its line
+// break. It returns io.EOF when the input ends or Ctrl+D is pressed on an
+// empty line.
+func (ed *Editor) ReadLine(ctx context.Context, prompt string) (string, error) {
+	if ed.MakeRaw == nil {
+		if ed.lines == nil {
+			ed.lines = newLineReader(ed.Input)
+		}
+		fmt.Fprint(ed.Output, prompt)
+		line, err := ed.lines.readLine(ctx)
+		if err != nil && (err != io.EOF || line == "") {
+			return "", err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		ed.remember(line)
+		return line, nil
+	}
+
+	if ed.keys == nil {
+		ed.keys = NewKeyReader(ed.Input)
+	}
+
+	restore, err := ed.MakeRaw()
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+
+	line, err := ed.edit(ctx, prompt)
+	fmt.Fprint(ed.Output, "\r\n")
+	if err != nil {
+		return "", err
+	}
+
+	ed.remember(line)
+	return line, nil
+}
+
+func (ed *Editor) remember(line string) {
+	if ed.History != nil {
+		_ = ed.History.Add(line)
+	}
+}
+
+// edit runs the editing loop on a raw terminal.
+func (ed *Editor) edit(ctx context.Context, prompt string) (string, error) {
+	var entries []string
+	if ed.History != nil {
+		entries = ed.History.Entries()
+	}
+	position := len(entries)
+	draft := ""
+
+	var line []rune
+	cursor := 0
+	lastKey := rune(0)
+
+	redraw := func() {
+		fmt.Fprintf(ed.Output, "\r\x1b[K%s%s", prompt, string(line))
+		if back := len(line) - cursor; back > 0 {
+			fmt.Fprintf(ed.Output, "\x1b[%dD", back)
+		}
+	}
+	show := func(text string) {
+		line = []rune(text)
+		cursor = len(line)
+		redraw()
+	}
+
+	redraw()
+	for {
+		key, err := ed.keys.ReadKey(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		switch key {
+		case keyEnter, keyNewLine:
+			return string(line), nil
+		case keyCtrlC:
+			return "", ErrInterrupted
+		case keyCtrlD:
+			if len(line) == 0 {
+				return "", io.EOF
+			}
+			if cursor < len(line) {
+				line = append(line[:cursor], line[cursor+1:]...)
+			}
+		case keyBackspace, keyDelete:
+			if cursor > 0 {
+				line = append(line[:cursor-1], line[cursor:]...)
+				cursor--
+			}
+		case KeyDeleteForward:
+			if cursor < len(line) {
+				line = append(line[:cursor], line[cursor+1:]...)
+			}
+		case keyCtrlA, KeyHome:
+			cursor = 0
+		case keyCtrlE, KeyEnd:
+			cursor = len(line)
+		case keyCtrlB, KeyLeft:
+			if cursor > 0 {
+				cursor--
+			}
+		case keyCtrlF, KeyRight:
+			if cursor < len(line) {
+				cursor++
+			}
+		case keyCtrlK:
+			line = line[:cursor]
+		case keyCtrlU:
+			line = append([]rune(nil), line[cursor:]...)
+			cursor = 0
+		case keyCtrlW:
+			start := cursor
+			for start > 0 && line[start-1] == ' ' {
+				start--
+			}
+			for start > 0 && line[start-1] != ' ' {
+				start--
+			}
+			line = append(line[:start], line[cursor:]...)
+			cursor = start
+		case keyCtrlL:
+			fmt.Fprint(ed.Output, "\x1b[H\x1b[2J")
+		case keyCtrlP, KeyUp:
+			if position > 0 {
+				if position == len(entries) {
+					draft = string(line)
+				}
+				position--
+				show(entries[position])
+			}
+			continue
+		case keyCtrlN, KeyDown:
+			if position < len(entries) {
+				position++
+				if position == len(entries) {
+					show(draft)
+				} else {
+					show(entries[position])
+				}
+			}
+			continue
+		case keyTab:
+			line, cursor = ed.complete(line, cursor, lastKey == keyTab, prompt)
+		default:
+			if key >= ' ' && key < keyDelete || key > keyDelete && key < KeyUp {
+				line = append(line[:cursor], append([]rune{key}, line[cursor:]...)...)
+				cursor++
+			}
+		}
+
+		lastKey = key
+		redraw()
+	}
+}
+
+// complete replaces the word before cursor with its only candidate or with
+// the prefix all candidates share. When that changes nothing and Tab was
+// pressed twice, it lists the candidates.
+func (ed *Editor) complete(line []rune, cursor int, listing bool, prompt string) ([]rune, int) {
+	if ed.Complete == nil {
+		return line, cursor
+	}
+
+	head := string(line[:cursor])
+	words := strings.Fields(head)
+	if head == "" || strings.HasSuffix(head, " ") {
+		words = append(words, "")
+	}
+	word := words[len(words)-1]
+
+	candidates := ed.Complete(words)
+	if len(candidates) == 0 {
+		return line, cursor
+	}
+
+	replacement := candidates[0] + " "
+	if len(candidates) > 1 {
+		replacement = commonPrefix(candidates)
+	}
+
+	if utf8.RuneCountInString(replacement) <= utf8.RuneCountInString(word) || !strings.HasPrefix(strings.ToUpper(replacement), strings.ToUpper(word)) {
+		if listing && len(candidates) > 1 {
+			sorted := append([]string(nil), candidates...)
+			sort.Strings(sorted)
+			fmt.Fprintf(ed.Output, "\r\n%s\r\n", strings.Join(sorted, "  "))
+		}
+		return line, cursor
+	}
+
+	start := cursor - utf8.RuneCountInString(word)
+	completed := append(append(append([]rune(nil), line[:start]...), []rune(replacement)...), line[cursor:]...)
+	return completed, start + utf8.RuneCountInString(replacement)
+}
+
+// commonPrefix returns the longest prefix, ignoring case, of values.
+func commonPrefix(values []string) string {
+	prefix := []rune(values[0])
+	for _, value := range values[1:] {
+		runes := []rune(value)
+		i := 0
+		for i < len(prefix) && i < len(runes) && strings.EqualFold(string(prefix[i]), string(runes[i])) {
+			i++
+		}
+		prefix = prefix[:i]
+	}
+	return string(prefix)
+}
+
+// Keys that arrive as escape sequences, as returned by ReadKey. They are
+// mapped past the Unicode range.
+const (
+	KeyUp = utf8.MaxRune + 1 + iota
+	KeyDown
+	KeyRight
+	KeyLeft
+	KeyHome
+	KeyEnd
+	KeyDeleteForward
+	KeyUnknown
+)
+
+// KeyReader reads keys from input in a single goroutine, started by the
+// first read. A read given up because its context is done leaves the key it
+// was waiting for to the next read instead of losing it.
+type KeyReader struct {
+	input *bufio.Reader
+	once  sync.Once
+	runes chan key
+}
+
+type key struct {
+	value rune
+	err   error
+}
+
+// NewKeyReader returns a KeyReader reading from input.
+func NewKeyReader(input io.Reader) *KeyReader {
+	return &KeyReader{input: bufio.NewReader(input), runes: make(chan key)}
+}
+
+// ReadKey reads a key, decoding UTF-8 characters and the escape sequences of
+// arrows, Home, End and Delete. It gives up as soon as ctx is done.
+func (r *KeyReader) ReadKey(ctx context.Context) (rune, error) {
+	key, err := r.readRune(ctx)
+	if err != nil || key != keyEscape {
+		return key, err
+	}
+
+	next, err := r.readRune(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if next != '[' && next != 'O' {
+		return KeyUnknown, nil
+	}
+
+	var sequence []rune
+	for {
+		value, err := r.readRune(ctx)
+		if err != nil {
+			return 0, err
+		}
+		sequence = append(sequence, value)
+		if value >= 'A' && value <= 'Z' || value == '~' {
+			break
+		}
+	}
+
+	switch string(sequence) {
+	case "A":
+		return KeyUp, nil
+	case "B":
+		return KeyDown, nil
+	case "C":
+		return KeyRight, nil
+	case "D":
+		return KeyLeft, nil
+	case "H", "1~", "7~":
+		return KeyHome, nil
+	case "F", "4~", "8~":
+		return KeyEnd, nil
+	case "3~":
+		return KeyDeleteForward, nil
+	default:
+		return KeyUnknown, nil
+	}
+}
+
+// readRune reads the next rune, giving up as soon as ctx is done. After the
+// input fails, every read returns io.EOF.
+func (r *KeyReader) readRune(ctx context.Context) (rune, error) {
+	r.once.Do(func() {
+		go r.run()
+	})
+
+	select {
+	case <-ctx.Done():
+		return 0, e.FromContext(ctx)
+	case k, ok := <-r.runes:
+		if !ok {
+			return 0, io.EOF
+		}
+		return k.value, k.err
+	}
+}
+
+// run sends every rune of the input until it fails.
+func (r *KeyReader) run() {
+	defer close(r.runes)
+	for {
+		value, _, err := r.input.ReadRune()
+		r.runes <- key{value: value, err: err}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package reader
+
+import (
+	e "buda-challenge/error"
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_WhenKeysEditTheLine_ReturnTheEditedLine(t *testing.T) {
+	editor := getEditor("abc\x1b[D\x1b[DX\x1b[F\x7fZ\x01>\r", nil)
+
+	line, err := editor.ReadLine(context.Background(), "> ")
+
+	assert.Nil(t, err)
+	assert.Equal(t, ">aXbZ", line)
+}
+
+func Test_WhenUpArrowIsPressed_ReturnTheLastEntry(t *testing.T) {
+	history, _ := LoadHistory("")
+	_ = history.Add("route A F GREEN")
+	_ = history.Add("stations")
+	editor := getEditor("\x1b[A\x1b[A\x1b[A\x1b[B\r", history)
+
+	line, err := editor.ReadLine(context.Background(), "> ")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "stations", line)
+	assert.Equal(t, []string{"route A F GREEN", "stations"}, history.Entries())
+}
+
+func Test_WhenTabIsPressed_CompleteTheWord(t *testing.T) {
+	editor := getEditor("route los\tb\t\r", nil)
+	editor.Complete = func(words []string) []string {
+		var candidates []string
+		for _, name := range []string{"Los Héroes", "Los Leones", "Baquedano"} {
+			if strings.HasPrefix(strings.ToUpper(name), strings.ToUpper(words[len(words)-1])) {
+				candidates = append(candidates, name)
+			}
+		}
+		return candidates
+	}
+
+	line, err := editor.ReadLine(context.Background(), "> ")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "route Los Baquedano ", line)
+}
+
+func Test_WhenCtrlDIsPressedOnAnEmptyLine_ReturnEOF(t *testing.T) {
+	editor := getEditor("\x04", nil)
+
+	_, err := editor.ReadLine(context.Background(), "> ")
+
+	assert.Equal(t, io.EOF, err)
+}
+
+func Test_WhenInputIsNotATerminal_ReadPlainLines(t *testing.T) {
+	history, _ := LoadHistory("")
+	editor := &Editor{Input: strings.NewReader("stations\nmap"), Output: &bytes.Buffer{}, History: history}
+
+	first, err := editor.ReadLine(context.Background(), "> ")
+	assert.Nil(t, err)
+	second, err := editor.ReadLine(context.Background(), "> ")
+	assert.Nil(t, err)
+	_, err = editor.ReadLine(context.Background(), "> ")
+
+	assert.Equal(t, "stations", first)
+	assert.Equal(t, "map", second)
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, []string{"stations", "map"}, history.Entries())
+}
+
+func Test_WhenHistoryHasAFile_KeepEntriesBetweenSessions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reader")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, ".metro_history")
+
+	history, err := LoadHistory(path)
+	assert.Nil(t, err)
+	assert.Nil(t, history.Add("stations"))
+	assert.Nil(t, history.Add("stations"))
+	assert.Nil(t, history.Add(" "))
+	assert.Nil(t, history.Add("map"))
+
+	reloaded, err := LoadHistory(path)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"stations", "map"}, reloaded.Entries())
+}
+
+func Test_WhenHistoryFileIsFull_KeepOnlyTheLastEntries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reader")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, ".metro_history")
+
+	history, err := LoadHistory(path)
+	assert.Nil(t, err)
+	for i := 0; i < maxHistory+5; i++ {
+		assert.Nil(t, history.Add(fmt.Sprintf("route %d", i)))
+	}
+
+	content, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	reloaded, err := LoadHistory(path)
+
+	assert.Nil(t, err)
+	assert.Equal(t, maxHistory, strings.Count(string(content), "\n"))
+	assert.Len(t, reloaded.Entries(), maxHistory)
+	assert.Equal(t, "route 5", reloaded.Entries()[0])
+	assert.Equal(t, fmt.Sprintf("route %d", maxHistory+4), reloaded.Entries()[maxHistory-1])
+}
+
+func Test_WhenAKeyReadIsCanceled_LeaveTheKeyItWaitedForToTheNextRead(t *testing.T) {
+	input, output := io.Pipe()
+	defer output.Close()
+	keys := NewKeyReader(input)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := keys.ReadKey(ctx)
+
+	assert.Equal(t, e.ErrorCanceled, err.Error())
+
+	go func() {
+		_, _ = output.Write([]byte("\x1b[A"))
+	}()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	key, err := keys.ReadKey(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, rune(KeyUp), key)
+}
+
+func getEditor(keys string, history *History) *Editor {
+	return &Editor{
+		Input:   strings.NewReader(keys),
+		Output:  &bytes.Buffer{},
+		History: history,
+		MakeRaw: func() (func() error, error) {
+			return func() error { return nil }, nil
+		},
+	}
+}
@@ -0,0 +1,101 @@
+package reader
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// maxHistory is the number of entries a History keeps.
+const maxHistory = 1000
+
+// History is the list of lines entered in previous and current sessions.
+// When it has a path, every added line is appended to that file, which is
+// rewritten with the kept entries once it holds more than maxHistory lines.
+type History struct {
+	path    string
+	entries []string
+	// lines is the number of lines in the file.
+	lines int
+}
+
+// LoadHistory reads the history kept at path. A missing file is an empty
+// history. An empty path keeps the history in memory only.
+func LoadHistory(path string) (*History, error) {
+	history := &History{path: path}
+	if path == "" {
+		return history, nil
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		history.lines++
+		if line := scanner.Text(); line != "" {
+			history.entries = append(history.entries, line)
+		}
+	}
+	history.trim()
+
+	return history, scanner.Err()
+}
+
+// Add appends line to the history unless it is blank or repeats the last
+// entry.
+func (h *History) Add(line string) error {
+	line = strings.TrimSpace(line)
+	if line == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == line) {
+		return nil
+	}
+
+	h.entries = append(h.entries, line)
+	h.trim()
+	if h.path == "" {
+		return nil
+	}
+	if h.lines >= maxHistory {
+		return h.rewrite()
+	}
+
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(line + "\n"); err != nil {
+		file.Close()
+		return err
+	}
+	h.lines++
+	return file.Close()
+}
+
+// rewrite replaces the file with the entries kept, so it does not grow
+// past maxHistory lines.
+func (h *History) rewrite() error {
+	content := strings.Join(h.entries, "\n") + "\n"
+	if err := ioutil.WriteFile(h.path, []byte(content), 0600); err != nil {
+		return err
+	}
+	h.lines = len(h.entries)
+	return nil
+}
+
+// Entries returns a copy of the history, oldest first.
+func (h *History) Entries() []string {
+	return append([]string(nil), h.entries...)
+}
+
+func (h *History) trim() {
+	if len(h.entries) > maxHistory {
+		h.entries = append([]string(nil), h.entries[len(h.entries)-maxHistory:]...)
+	}
+}
@@ -2,39 +2,54 @@ package reader
 
 import (
 	"buda-challenge/dto"
+	e "buda-challenge/error"
+	"buda-challenge/i18n"
+	"buda-challenge/logger"
+	"buda-challenge/matcher"
 	"buda-challenge/validator"
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"strings"
+	"sync"
 )
 
 type Reader interface {
-	ReadInput(stations, colors []string) (dto.Configuration, error)
-	ReadFile(fileName string) ([]dto.Station, error)
-	Read(requiredValue string, validValues []string) (string, error)
+	ReadInput(ctx context.Context, stations, colors []dto.Option) (dto.Configuration, error)
+	ReadFile(ctx context.Context, fileName string) (dto.Network, error)
+	Read(ctx context.Context, requiredValue string, options []dto.Option) (string, error)
 }
 
 type ReaderImpl struct {
 	Validator validator.Validator
 	Mocked    func() (string, error)
+	Logger    *logger.Logger
+	// Language of the prompts. English when empty.
+	Language  i18n.Language
 }
 
-func(r ReaderImpl) ReadInput(stations, colors []string) (dto.Configuration, error) {
-	initialStation, err := r.Read("initial station", stations)
+// stdin is shared by every prompt so that lines buffered while reading one
+// answer are not lost for the next.
+var stdin = newLineReader(os.Stdin)
+
+func(r ReaderImpl) ReadInput(ctx context.Context, stations, colors []dto.Option) (dto.Configuration, error) {
+	initialStation, err := r.Read(ctx, "initial station", stations)
 	if err != nil {
 		return dto.Configuration{}, err
 	}
 
-	finalStation, err := r.Read("final station", stations)
+	finalStation, err := r.Read(ctx, "final station", stations)
 	if err != nil {
 		return dto.Configuration{}, err
 	}
 
-	trainColor, err := r.Read("train color", colors)
+	trainColor, err := r.Read(ctx, "train color", colors)
 	if err != nil {
 		return dto.Configuration{}, err
 	}
@@ -46,42 +61,145 @@ func(r ReaderImpl) ReadInput(stations, colors []string) (dto.Configuration, erro
 	}, nil
 }
 
-func(r ReaderImpl) Read(requiredValue string, validValues []string) (string, error) {
+// maxAttempts is the number of invalid answers Read accepts before giving up.
+const maxAttempts = 3
+
+// maxSuggestions is the number of options suggested after an invalid answer.
+const maxSuggestions = 3
+
+// Read prompts for requiredValue until the entered text matches the value,
+// label or an alias of one of options, and returns the value of that option.
+// Case, accents and punctuation are ignored. After an invalid answer it
+// suggests the closest options, and it gives up after maxAttempts of them.
+func(r ReaderImpl) Read(ctx context.Context, requiredValue string, options []dto.Option) (string, error) {
 	if r.Mocked != nil {
 		return "", errors.New("mocked to test")
 	}
 
-	reader := bufio.NewReader(os.Stdin)
-	var enteredValue string
+	labels := make([]string, len(options))
+	for i, option := range options {
+		labels[i] = option.Label
+	}
 
-	for {
-		fmt.Print("Enter " + requiredValue + " [ Valid values: " + strings.Join(validValues, " - ") + " ] : ")
-		input, err := reader.ReadString('\n')
+	for attempt := 1; ; attempt++ {
+		fmt.Print(i18n.Message(r.Language, "Enter %s [ Valid values: %s ] : ", i18n.Message(r.Language, requiredValue), strings.Join(labels, " - ")))
+		input, err := stdin.readLine(ctx)
 		if err != nil {
 			return "", err
 		}
 
-		enteredValue = strings.ToUpper(strings.TrimSuffix(input, "\n"))
-		if r.Validator.Validate(enteredValue, validValues) {
-			break
+		enteredValue := strings.TrimSpace(input)
+		if value, ok := r.match(enteredValue, options); ok {
+			r.Logger.Debug(ctx, "input read", logger.Fields{"field": requiredValue, "value": value})
+			return value, nil
+		}
+		r.Logger.Debug(ctx, "invalid input", logger.Fields{"field": requiredValue, "value": enteredValue, "attempt": attempt})
+
+		if attempt == maxAttempts {
+			fmt.Println(i18n.Message(r.Language, "Invalid value!"))
+			return "", errors.New(e.ErrorReadingInput)
+		}
+
+		suggestions := matcher.Autocomplete(enteredValue, options, maxSuggestions)
+		if len(suggestions) == 0 {
+			fmt.Println(i18n.Message(r.Language, "Invalid value! Try again!"))
+			continue
 		}
-		fmt.Println("Invalid value! Try again!")
+
+		names := make([]string, len(suggestions))
+		for i, suggestion := range suggestions {
+			names[i] = suggestion.Label
+		}
+		fmt.Println(i18n.Message(r.Language, "Invalid value! Did you mean %s?", strings.Join(names, ", ")))
 	}
+}
 
-	return enteredValue, nil
+// match returns the value of the option known as enteredValue, ignoring
+// case, accents and punctuation.
+func(r ReaderImpl) match(enteredValue string, options []dto.Option) (string, bool) {
+	normalized := matcher.Normalize(enteredValue)
+	for _, option := range options {
+		if r.Validator.Validate(normalized, matcher.Names(option)) {
+			return option.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// lineReader reads lines from input in a single goroutine, started by the
+// first read. A read given up because its context is done leaves the line it
+// was waiting for to the next read instead of losing it.
+type lineReader struct {
+	input *bufio.Reader
+	once  sync.Once
+	lines chan line
 }
 
-func(r ReaderImpl) ReadFile(fileName string) ([]dto.Station, error) {
+type line struct {
+	value string
+	err   error
+}
+
+func newLineReader(input io.Reader) *lineReader {
+	return &lineReader{input: bufio.NewReader(input), lines: make(chan line)}
+}
+
+// readLine reads the next line, giving up as soon as ctx is done. After the
+// input fails, every read returns io.EOF.
+func (r *lineReader) readLine(ctx context.Context) (string, error) {
+	r.once.Do(func() {
+		go r.run()
+	})
+
+	select {
+	case <-ctx.Done():
+		return "", e.FromContext(ctx)
+	case l, ok := <-r.lines:
+		if !ok {
+			return "", io.EOF
+		}
+		return l.value, l.err
+	}
+}
+
+// run sends every line of the input until it fails.
+func (r *lineReader) run() {
+	defer close(r.lines)
+	for {
+		value, err := r.input.ReadString('\n')
+		r.lines <- line{value: value, err: err}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// ReadFile reads a network file. The file is either an object with the
+// stations and the colors of the network or, as it used to be, the array
+// of stations alone.
+func(r ReaderImpl) ReadFile(ctx context.Context, fileName string) (dto.Network, error) {
+	if err := e.FromContext(ctx); err != nil {
+		return dto.Network{}, err
+	}
+
 	content, err := ioutil.ReadFile(fileName)
 	if err != nil {
-		return []dto.Station{}, err
+		r.Logger.Error(ctx, "unable to read network file", logger.Fields{"file": fileName, "error": err})
+		return dto.Network{}, err
 	}
 
-	var stations []dto.Station
-	err = json.Unmarshal(content, &stations)
+	var network dto.Network
+	if trimmed := bytes.TrimSpace(content); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(content, &network.Stations)
+	} else {
+		err = json.Unmarshal(content, &network)
+	}
 	if err != nil {
-		return []dto.Station{}, err
+		r.Logger.Error(ctx, "unable to decode network file", logger.Fields{"file": fileName, "error": err})
+		return dto.Network{}, err
 	}
 
-	return stations, nil
+	r.Logger.Debug(ctx, "network file read", logger.Fields{"file": fileName, "bytes": len(content)})
+	return network, nil
 }
@@ -2,10 +2,19 @@ package reader
 
 import (
 	"buda-challenge/dto"
+	e "buda-challenge/error"
 	"buda-challenge/validator"
+	"context"
+	"encoding/json"
 	"errors"
+	"io"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 const (
@@ -26,28 +35,176 @@ const (
 )
 
 func Test_GivenAValidTrainNetworkFilePath_ReturnStations(t *testing.T) {
-	result, err := ReaderImpl{}.ReadFile(trainNetworkFileValidPath)
+	result, err := ReaderImpl{}.ReadFile(context.Background(), trainNetworkFileValidPath)
 
-	assert.Equal(t, getStations(), result)
+	assert.Equal(t, getStations(), result.Stations)
+	assert.Equal(t, dto.Color{Name: trainGreen, Names: map[string]string{"en": trainGreen, "es": "VERDE"}}, result.Colors[1])
 	assert.Nil(t, err)
 }
 
+func Test_GivenATrainNetworkFileWithAnArrayOfStations_ReturnStations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reader")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "train_network.json")
+	content, _ := json.Marshal(getStations())
+	assert.Nil(t, ioutil.WriteFile(path, content, 0644))
+
+	result, err := ReaderImpl{}.ReadFile(context.Background(), path)
+
+	assert.Equal(t, dto.Network{Stations: getStations()}, result)
+	assert.Nil(t, err)
+}
+
+func Test_GivenATrainNetworkFileWithNestedForks_ReturnEveryBranch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reader")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "train_network.json")
+	content := `{"stations": [
+		{"name": "Vicente Valdés", "train_color": "WITHOUT COLOR", "forks": [
+			[
+				{"name": "Mirador", "train_color": "WITHOUT COLOR", "forks": [
+					[{"name": "Macul", "train_color": "GREEN"}],
+					[{"name": "Camino Agrícola", "train_color": "RED"}]
+				]}
+			],
+			[{"name": "Bellavista", "train_color": "RED"}]
+		]},
+		{"name": "La Florida", "train_color": "WITHOUT COLOR"}
+	]}`
+	assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+
+	result, err := ReaderImpl{}.ReadFile(context.Background(), path)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []dto.Station{
+		{Name: "Vicente Valdés", TrainColor: trainWithoutColour, Forks: [][]dto.Station{
+			{
+				{Name: "Mirador", TrainColor: trainWithoutColour, Forks: [][]dto.Station{
+					{{Name: "Macul", TrainColor: trainGreen}},
+					{{Name: "Camino Agrícola", TrainColor: trainRed}},
+				}},
+			},
+			{{Name: "Bellavista", TrainColor: trainRed}},
+		}},
+		{Name: "La Florida", TrainColor: trainWithoutColour},
+	}, result.Stations)
+}
+
+func Test_WhenEnteredValueIsAnAliasInAnyCase_ReturnTheOptionValue(t *testing.T) {
+	options := []dto.Option{
+		{Value: trainRed, Label: "Rojo", Aliases: []string{trainRed}},
+		{Value: trainGreen, Label: "VERDE", Aliases: []string{trainGreen}},
+	}
+
+	value, ok := ReaderImpl{Validator: validator.ValidatorImpl{}}.match("VERDE", options)
+
+	assert.True(t, ok)
+	assert.Equal(t, trainGreen, value)
+
+	value, ok = ReaderImpl{Validator: validator.ValidatorImpl{}}.match("ROJO", options)
+
+	assert.True(t, ok)
+	assert.Equal(t, trainRed, value)
+
+	_, ok = ReaderImpl{Validator: validator.ValidatorImpl{}}.match("AZUL", options)
+
+	assert.False(t, ok)
+}
+
 func Test_GivenAInvalidTrainNetworkFilePath_ReturnError(t *testing.T) {
-	_, err := ReaderImpl{}.ReadFile(trainNetworkFileInvalidPath)
+	_, err := ReaderImpl{}.ReadFile(context.Background(), trainNetworkFileInvalidPath)
 
 	assert.NotNil(t, err)
 }
 
+func Test_WhenContextIsCanceled_ReadFileReturnsError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := ReaderImpl{}.ReadFile(ctx, trainNetworkFileValidPath)
+
+	assert.Equal(t, e.ErrorCanceled, err.Error())
+}
+
 func Test_WhenInputsCanNotBeReadCorrectly_ReturnError(t *testing.T) {
 	reader := ReaderImpl{Validator: validator.ValidatorImpl{}, Mocked: func() (string, error) {
 		return "", errors.New("mocked to test")
 	}}
 
-	_, err := reader.ReadInput([]string{stationA, stationB, stationC}, []string{trainRed, trainGreen, trainWithoutColour})
+	_, err := reader.ReadInput(context.Background(), getOptions(stationA, stationB, stationC), getOptions(trainRed, trainGreen, trainWithoutColour))
 
 	assert.NotNil(t, err)
 }
 
+func Test_WhenEnteredValueHasAccentsAndLowercase_ReturnTheOptionValue(t *testing.T) {
+	stdin = newLineReader(strings.NewReader("los heroes\n"))
+	defer func() { stdin = newLineReader(os.Stdin) }()
+
+	value, err := ReaderImpl{Validator: validator.ValidatorImpl{}}.Read(context.Background(), "initial station", []dto.Option{
+		{Value: stationA, Label: "Baquedano"},
+		{Value: stationB, Label: "Los Héroes"},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, stationB, value)
+}
+
+func Test_WhenEnteredValueHasATypo_AcceptTheNextAnswer(t *testing.T) {
+	stdin = newLineReader(strings.NewReader("Baqedano\nbaquedano\n"))
+	defer func() { stdin = newLineReader(os.Stdin) }()
+
+	value, err := ReaderImpl{Validator: validator.ValidatorImpl{}}.Read(context.Background(), "initial station", []dto.Option{
+		{Value: stationA, Label: "Baquedano"},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, stationA, value)
+}
+
+func Test_WhenEveryAttemptIsInvalid_ReturnError(t *testing.T) {
+	stdin = newLineReader(strings.NewReader("Z\nY\nX\nA\n"))
+	defer func() { stdin = newLineReader(os.Stdin) }()
+
+	_, err := ReaderImpl{Validator: validator.ValidatorImpl{}}.Read(context.Background(), "initial station", getOptions(stationA, stationB))
+
+	assert.NotNil(t, err)
+	assert.Equal(t, e.ErrorReadingInput, err.Error())
+}
+
+func Test_WhenAReadIsCanceled_LeaveTheLineItWaitedForToTheNextRead(t *testing.T) {
+	input, output := io.Pipe()
+	defer output.Close()
+	lines := newLineReader(input)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := lines.readLine(ctx)
+
+	assert.Equal(t, e.ErrorCanceled, err.Error())
+
+	go func() {
+		_, _ = output.Write([]byte("baquedano\n"))
+	}()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	value, err := lines.readLine(ctx)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "baquedano\n", value)
+}
+
+func getOptions(values ...string) []dto.Option {
+	options := make([]dto.Option, len(values))
+	for i, value := range values {
+		options[i] = dto.Option{Value: value, Label: value}
+	}
+	return options
+}
+
 func getStations() []dto.Station {
 	stationA := dto.Station{Name: stationA, Forks: nil, TrainColor: trainWithoutColour}
 	stationB := dto.Station{Name: stationB, Forks: nil, TrainColor: trainWithoutColour}
@@ -0,0 +1,26 @@
+package render
+
+import (
+	"buda-challenge/diagram"
+	"buda-challenge/dto"
+	"buda-challenge/network"
+	"fmt"
+	"io"
+)
+
+// RenderDiagram writes the network of snapshot as an ASCII diagram with the
+// stops of trainColor in brackets and the physical path of result
+// highlighted, followed by a legend.
+func RenderDiagram(w io.Writer, snapshot *network.Snapshot, stops map[string]bool, result dto.Result, trainColor string) error {
+	var route []string
+	for _, station := range result.Path {
+		route = append(route, station.Name)
+	}
+
+	if err := diagram.Of(snapshot).Write(w, diagram.Marks{Stops: stops, Route: route}); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\n[X] %s train stops  (X) %s train passes through  === route\n", trainColor, trainColor)
+	return err
+}
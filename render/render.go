@@ -0,0 +1,171 @@
+package render
+
+import (
+	"buda-challenge/dto"
+	"buda-challenge/i18n"
+	"buda-challenge/itinerary"
+	"buda-challenge/network"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+type Format string
+
+const (
+	FormatText  Format = "text"
+	FormatJSON  Format = "json"
+	FormatTable Format = "table"
+	// FormatItinerary writes step by step instructions, see RenderJourney.
+	FormatItinerary Format = "itinerary"
+)
+
+var formats = []Format{FormatText, FormatJSON, FormatTable, FormatItinerary}
+
+func ParseFormat(name string) (Format, error) {
+	for _, format := range formats {
+		if strings.EqualFold(name, string(format)) {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown output %q, valid values: text, json, table, itinerary", name)
+}
+
+// Render writes result, or err when it is not nil, in format. The text
+// formats are written in language.
+func Render(w io.Writer, format Format, result dto.Result, language i18n.Language, err error) error {
+	switch format {
+	case FormatJSON:
+		return renderJSON(w, result, err)
+	case FormatTable:
+		return renderTable(w, result, language, err)
+	case FormatItinerary:
+		return RenderJourney(w, []dto.Result{result}, nil, language, err)
+	default:
+		return renderText(w, result, language, err)
+	}
+}
+
+// RenderJourney writes the numbered instructions of journey in language,
+// naming stations and colors as snapshot does, or err when it is not nil.
+func RenderJourney(w io.Writer, journey []dto.Result, snapshot *network.Snapshot, language i18n.Language, err error) error {
+	if err != nil {
+		return renderError(w, language, err)
+	}
+
+	return itinerary.Write(w, journey, snapshot, language)
+}
+
+func renderError(w io.Writer, language i18n.Language, err error) error {
+	_, writeErr := fmt.Fprintln(w, i18n.Message(language, "Error:"), err)
+	return writeErr
+}
+
+func renderText(w io.Writer, result dto.Result, language i18n.Language, err error) error {
+	if err != nil {
+		return renderError(w, language, err)
+	}
+
+	if _, err := fmt.Fprintln(w, i18n.Message(language, "Shortest route:"), strings.Join(result.Stops, " ")); err != nil {
+		return err
+	}
+	if len(result.PassedThrough) > 0 {
+		if _, err := fmt.Fprintln(w, i18n.Message(language, "Path:"), Path(result.Path)); err != nil {
+			return err
+		}
+	}
+	if result.Expected != nil {
+		expected := i18n.Message(language, "Expected time: %s minutes, %s of them waiting", strconv.FormatFloat(result.Expected.TotalMinutes, 'f', -1, 64), strconv.FormatFloat(result.Expected.WaitMinutes, 'f', -1, 64))
+		if _, err := fmt.Fprintln(w, expected); err != nil {
+			return err
+		}
+	}
+	for _, diagnostic := range result.Diagnostics {
+		if _, err := fmt.Fprintln(w, i18n.Message(language, "Note:"), Diagnostic(language, diagnostic)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func renderJSON(w io.Writer, result dto.Result, err error) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err != nil {
+		return encoder.Encode(errorResponse{Error: err.Error()})
+	}
+	return encoder.Encode(result)
+}
+
+func renderTable(w io.Writer, result dto.Result, language i18n.Language, err error) error {
+	if err != nil {
+		return renderError(w, language, err)
+	}
+
+	headers := []string{"SEGMENT", "FROM", "TO", "PASSED THROUGH", "DISTANCE"}
+	for i, header := range headers {
+		headers[i] = i18n.Message(language, header)
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, strings.Join(headers, "\t"))
+	for i, segment := range result.Segments {
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\t%d\n", i+1, segment.From, segment.To, orDash(segment.PassedThrough), segment.Distance)
+	}
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%d\n", i18n.Message(language, "TOTAL"), first(result.Stops), last(result.Stops), i18n.Message(language, "%d passed", result.Totals.PassedThrough), result.Totals.Distance)
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s\n%s %s\n", i18n.Message(language, "%s train, %d stops, network %s", result.TrainColor, result.Totals.Stops, result.NetworkVersion), i18n.Message(language, "Path:"), Path(result.Path))
+	return err
+}
+
+// Diagnostic translates diagnostic to language.
+func Diagnostic(language i18n.Language, diagnostic dto.Diagnostic) string {
+	return i18n.Message(language, diagnostic.Message, diagnostic.Arguments()...)
+}
+
+// Path joins the stations of path with arrows, putting the ones the train
+// passes through without stopping in parentheses: F → I → (H) → G.
+func Path(path []dto.PathStation) string {
+	names := make([]string, 0, len(path))
+	for _, station := range path {
+		if station.Stop {
+			names = append(names, station.Name)
+		} else {
+			names = append(names, "("+station.Name+")")
+		}
+	}
+	return strings.Join(names, " → ")
+}
+
+func orDash(values []string) string {
+	if len(values) == 0 {
+		return "-"
+	}
+	return strings.Join(values, " ")
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func last(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[len(values)-1]
+}
@@ -0,0 +1,194 @@
+package render
+
+import (
+	"buda-challenge/dto"
+	e "buda-challenge/error"
+	"buda-challenge/i18n"
+	"buda-challenge/network"
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_GivenAResult_RenderItAsText(t *testing.T) {
+	var output bytes.Buffer
+
+	err := Render(&output, FormatText, getResult(), i18n.English, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Shortest route: F I G C\nPath: F → I → (H) → G → C\nNote: GREEN train passes through H without stopping\n", output.String())
+}
+
+func Test_GivenAResultWithExpectedTime_RenderItAsText(t *testing.T) {
+	var output bytes.Buffer
+	result := getResult()
+	result.Diagnostics = nil
+	result.Expected = &dto.Expectation{TrainColor: "GREEN", WaitMinutes: 2.5, RideMinutes: 11, TotalMinutes: 13.5}
+
+	err := Render(&output, FormatText, result, i18n.English, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Shortest route: F I G C\nPath: F → I → (H) → G → C\nExpected time: 13.5 minutes, 2.5 of them waiting\n", output.String())
+}
+
+func Test_GivenAResultInSpanish_RenderItsLabelsInSpanish(t *testing.T) {
+	var output bytes.Buffer
+	result := getResult()
+	result.Diagnostics = nil
+	result.Expected = &dto.Expectation{TrainColor: "GREEN", WaitMinutes: 2.5, RideMinutes: 11, TotalMinutes: 13.5}
+
+	err := Render(&output, FormatText, result, i18n.Spanish, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Ruta más corta: F I G C\nRecorrido: F → I → (H) → G → C\nTiempo esperado: 13.5 minutos, 2.5 de ellos esperando\n", output.String())
+}
+
+func Test_GivenADiagnosticInSpanish_RenderItTranslated(t *testing.T) {
+	var output bytes.Buffer
+	result := getResult()
+	result.PassedThrough = nil
+
+	err := Render(&output, FormatText, result, i18n.Spanish, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Ruta más corta: F I G C\nNota: El tren GREEN pasa por H sin detenerse\n", output.String())
+}
+
+func Test_GivenAServiceWindowDiagnosticInSpanish_RenderItTranslated(t *testing.T) {
+	var output bytes.Buffer
+	result := getResult()
+	result.PassedThrough = nil
+	result.Diagnostics = []dto.Diagnostic{dto.NewDiagnostic("%s trains stop as %s trains at %s, outside their service windows", "GREEN", "WITHOUT COLOR", "12:00")}
+
+	err := Render(&output, FormatText, result, i18n.Spanish, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Ruta más corta: F I G C\nNota: Los trenes GREEN se detienen como trenes WITHOUT COLOR a las 12:00, fuera de sus horarios de servicio\n", output.String())
+}
+
+func Test_GivenAFasterLocalDiagnosticInSpanish_RenderItTranslated(t *testing.T) {
+	var output bytes.Buffer
+	result := getResult()
+	result.PassedThrough = nil
+	result.Diagnostics = []dto.Diagnostic{dto.NewDiagnostic("Taking the next %s train is expected to be faster: %s minutes instead of %s", "WITHOUT COLOR", "20", "26")}
+
+	err := Render(&output, FormatText, result, i18n.Spanish, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Ruta más corta: F I G C\nNota: Se espera que tomar el próximo tren WITHOUT COLOR sea más rápido: 20 minutos en lugar de 26\n", output.String())
+}
+
+func Test_GivenAResult_RenderItAsJSON(t *testing.T) {
+	var output bytes.Buffer
+
+	err := Render(&output, FormatJSON, getResult(), i18n.English, nil)
+
+	assert.Nil(t, err)
+	assert.Contains(t, output.String(), `"stops": [`)
+	assert.Contains(t, output.String(), `"passed_through": [`)
+	assert.Contains(t, output.String(), `"network_version": "abc"`)
+	assert.Contains(t, output.String(), `"diagnostics": [
+    "GREEN train passes through H without stopping"
+  ]`)
+	assert.Contains(t, output.String(), `"path": [
+    {
+      "name": "F",
+      "stop": true
+    },`)
+	assert.Contains(t, output.String(), `{
+      "name": "H",
+      "stop": false
+    }`)
+}
+
+func Test_GivenAResult_RenderItAsTable(t *testing.T) {
+	var output bytes.Buffer
+
+	err := Render(&output, FormatTable, getResult(), i18n.English, nil)
+
+	expected := "SEGMENT  FROM  TO  PASSED THROUGH  DISTANCE\n" +
+		"1        F     I   -               1\n" +
+		"2        I     G   H               2\n" +
+		"3        G     C   -               1\n" +
+		"TOTAL    F     C   1 passed        4\n" +
+		"GREEN train, 4 stops, network abc\n" +
+		"Path: F → I → (H) → G → C\n"
+	assert.Nil(t, err)
+	assert.Equal(t, expected, output.String())
+}
+
+func Test_GivenAResultInSpanish_RenderItAsTable(t *testing.T) {
+	var output bytes.Buffer
+
+	err := Render(&output, FormatTable, getResult(), i18n.Spanish, nil)
+
+	expected := "TRAMO  DESDE  HASTA  SIN PARADA    DISTANCIA\n" +
+		"1      F      I      -             1\n" +
+		"2      I      G      H             2\n" +
+		"3      G      C      -             1\n" +
+		"TOTAL  F      C      1 sin parada  4\n" +
+		"Tren GREEN, 4 paradas, red abc\n" +
+		"Recorrido: F → I → (H) → G → C\n"
+	assert.Nil(t, err)
+	assert.Equal(t, expected, output.String())
+}
+
+func Test_GivenAnError_RenderItInEachFormat(t *testing.T) {
+	var text, json bytes.Buffer
+	err := errors.New(e.ErrorInvalidCombination)
+
+	_ = Render(&text, FormatText, dto.Result{}, i18n.English, err)
+	_ = Render(&json, FormatJSON, dto.Result{}, i18n.English, err)
+
+	assert.Equal(t, "Error: invalid combination\n", text.String())
+	assert.Equal(t, "{\n  \"error\": \"invalid combination\"\n}\n", json.String())
+}
+
+func Test_GivenAFormatName_ReturnTheFormat(t *testing.T) {
+	format, err := ParseFormat("JSON")
+	assert.Nil(t, err)
+	assert.Equal(t, FormatJSON, format)
+
+	_, err = ParseFormat("xml")
+	assert.NotNil(t, err)
+}
+
+func getResult() dto.Result {
+	return dto.Result{
+		Stops:         []string{"F", "I", "G", "C"},
+		PassedThrough: []string{"H"},
+		Path: []dto.PathStation{
+			{Name: "F", Stop: true},
+			{Name: "I", Stop: true},
+			{Name: "H", Stop: false},
+			{Name: "G", Stop: true},
+			{Name: "C", Stop: true},
+		},
+		Segments: []dto.Segment{
+			{From: "F", To: "I", PassedThrough: []string{}, Distance: 1},
+			{From: "I", To: "G", PassedThrough: []string{"H"}, Distance: 2},
+			{From: "G", To: "C", PassedThrough: []string{}, Distance: 1},
+		},
+		Totals:         dto.Totals{Stops: 4, PassedThrough: 1, Segments: 3, Distance: 4},
+		TrainColor:     "GREEN",
+		NetworkVersion: "abc",
+		Diagnostics:    []dto.Diagnostic{dto.NewDiagnostic("%s train passes through %s without stopping", "GREEN", "H")},
+	}
+}
+
+func Test_GivenAResult_RenderTheDiagramWithALegend(t *testing.T) {
+	var output bytes.Buffer
+	var stations []dto.Station
+	for _, name := range []string{"F", "I", "H", "G", "C"} {
+		stations = append(stations, dto.Station{Name: name, TrainColor: "GREEN"})
+	}
+	snapshot, err := network.NewSnapshot(dto.Network{Stations: stations}, time.Now())
+	assert.Nil(t, err)
+
+	err = RenderDiagram(&output, snapshot, map[string]bool{"F": true, "I": true, "G": true, "C": true}, getResult(), "GREEN")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "[F]===[I]===(H)===[G]===[C]\n\n[X] GREEN train stops  (X) GREEN train passes through  === route\n", output.String())
+}
@@ -0,0 +1,434 @@
+// Package repl implements an interactive shell that keeps the network
+// loaded between queries.
+package repl
+
+import (
+	"buda-challenge/diagram"
+	"buda-challenge/dto"
+	"buda-challenge/handler"
+	"buda-challenge/i18n"
+	"buda-challenge/itinerary"
+	"buda-challenge/matcher"
+	"buda-challenge/network"
+	"buda-challenge/reader"
+	"buda-challenge/render"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// REPL reads commands with Editor and writes their answers to Output.
+type REPL struct {
+	Handler handler.Handler
+	// Store, when set, is reloaded by the reload command.
+	Store    *network.Store
+	Editor   *reader.Editor
+	Output   io.Writer
+	Language i18n.Language
+	// DepartureTime, as HH:MM, is given to every route. When empty, every
+	// color runs its own stopping pattern.
+	DepartureTime string
+	// Date, as YYYY-MM-DD, is given to every route so the calendars of the
+	// handler apply. When empty, every color runs every day.
+	Date string
+
+	last *dto.Configuration
+}
+
+type command struct {
+	name  string
+	usage string
+	help  string
+	run   func(r *REPL, ctx context.Context, args []string) error
+}
+
+var commands []command
+
+// init fills commands because help, one of them, reads the list.
+func init() {
+	commands = []command{
+		{"route", "route <from> <to> [color]", "find the shortest route, by a train without color unless a color is given", (*REPL).route},
+		{"stations", "stations", "list the stations and the other names they are known by", (*REPL).stations},
+		{"colors", "colors", "list the train colors", (*REPL).colors},
+		{"explain", "explain", "give step by step instructions for the last route", (*REPL).explain},
+		{"alternatives", "alternatives", "compare every train color between the stations of the last route", (*REPL).alternatives},
+		{"map", "map", "draw the network", (*REPL).drawMap},
+		{"reload", "reload", "read the network file again", (*REPL).reload},
+		{"history", "history", "list the commands entered", (*REPL).history},
+		{"help", "help", "list the commands", (*REPL).help},
+		{"exit", "exit", "leave the shell", nil},
+	}
+}
+
+// errUsage is returned by commands called with the wrong arguments.
+var errUsage = errors.New("usage")
+
+// Run reads and executes commands until exit is entered or the input ends.
+func (r *REPL) Run(ctx context.Context) error {
+	if r.Editor.Complete == nil {
+		r.Editor.Complete = func(words []string) []string {
+			return r.complete(ctx, words)
+		}
+	}
+
+	r.printf("%s\n", i18n.Message(r.Language, "Type help to list the commands."))
+	for {
+		line, err := r.Editor.ReadLine(ctx, "metro> ")
+		if err == reader.ErrInterrupted {
+			continue
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if quit := r.Execute(ctx, line); quit {
+			return nil
+		}
+	}
+}
+
+// Execute runs a command line and reports whether it asks to leave.
+func (r *REPL) Execute(ctx context.Context, line string) bool {
+	args := split(line)
+	if len(args) == 0 {
+		return false
+	}
+
+	name := strings.ToLower(args[0])
+	if name == "quit" {
+		name = "exit"
+	}
+	for _, c := range commands {
+		if c.name != name {
+			continue
+		}
+		if c.run == nil {
+			return true
+		}
+
+		err := c.run(r, ctx, args[1:])
+		if err == errUsage {
+			r.printf("%s: %s\n", i18n.Message(r.Language, "Usage"), c.usage)
+		} else if err != nil {
+			r.printf("%s %s\n", i18n.Message(r.Language, "Error:"), i18n.Error(r.Language, err))
+		}
+		return false
+	}
+
+	r.printf(i18n.Message(r.Language, "Unknown command %q. Type help to list the commands.")+"\n", args[0])
+	return false
+}
+
+func (r *REPL) route(ctx context.Context, args []string) error {
+	if len(args) < 2 {
+		return errUsage
+	}
+
+	snapshot, err := r.Handler.Configuration.GetNetwork(ctx)
+	if err != nil {
+		return err
+	}
+
+	config, ok := r.parseRoute(snapshot, args)
+	if !ok {
+		return nil
+	}
+
+	result, err := r.Handler.HandleSnapshotQuery(ctx, snapshot, config)
+	r.last = &config
+	return render.Render(r.Output, render.FormatText, result, r.Language, i18n.Error(r.Language, err))
+}
+
+// parseRoute finds how args split into the initial station, the final
+// station and an optional color, so that names with spaces need no quotes.
+// It explains what it did not recognize when no split works.
+func (r *REPL) parseRoute(snapshot *network.Snapshot, args []string) (dto.Configuration, bool) {
+	stations := snapshot.StationOptions(r.Language)
+	colors := r.colorOptions(snapshot)
+
+	for i := 1; i < len(args); i++ {
+		initial, ok := matcher.Match(strings.Join(args[:i], " "), stations)
+		if !ok {
+			continue
+		}
+		for j := i + 1; j <= len(args); j++ {
+			final, ok := matcher.Match(strings.Join(args[i:j], " "), stations)
+			if !ok {
+				continue
+			}
+
+			color := dto.Option{Value: r.Handler.Configuration.GetTrainWithoutColor()}
+			if j < len(args) {
+				if color, ok = matcher.Match(strings.Join(args[j:], " "), colors); !ok {
+					continue
+				}
+			}
+
+			return dto.Configuration{
+				InitialStation: initial.Value,
+				FinalStation:   final.Value,
+				TrainColor:     color.Value,
+				DepartureTime:  r.DepartureTime,
+				Date:           r.Date,
+			}, true
+		}
+	}
+
+	r.explainUnknown(args, stations, colors)
+	return dto.Configuration{}, false
+}
+
+// explainUnknown points at the first argument that names neither a station
+// nor a color, suggesting the closest names.
+func (r *REPL) explainUnknown(args []string, stations, colors []dto.Option) {
+	for i, arg := range args {
+		options := stations
+		if i >= 2 {
+			options = colors
+		}
+		if _, ok := matcher.Match(arg, options); ok {
+			continue
+		}
+		if _, ok := matcher.Match(arg, append(append([]dto.Option(nil), stations...), colors...)); ok {
+			continue
+		}
+
+		r.printf(i18n.Message(r.Language, "Unknown name %q.")+"\n", arg)
+		if suggestions := matcher.Autocomplete(arg, append(append([]dto.Option(nil), stations...), colors...), 3); len(suggestions) > 0 {
+			r.printf(i18n.Message(r.Language, "Did you mean %s?")+"\n", strings.Join(labels(suggestions), ", "))
+		}
+		return
+	}
+
+	r.printf("%s\n", i18n.Message(r.Language, "Enter the initial station, the final station and, optionally, the train color."))
+}
+
+func (r *REPL) stations(ctx context.Context, args []string) error {
+	snapshot, err := r.Handler.Configuration.GetNetwork(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, option := range snapshot.StationOptions(r.Language) {
+		r.printOption(option)
+	}
+	return nil
+}
+
+func (r *REPL) colors(ctx context.Context, args []string) error {
+	snapshot, err := r.Handler.Configuration.GetNetwork(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, option := range r.colorOptions(snapshot) {
+		r.printOption(option)
+	}
+	return nil
+}
+
+func (r *REPL) explain(ctx context.Context, args []string) error {
+	if r.last == nil {
+		r.printf("%s\n", i18n.Message(r.Language, "There is no route yet, use route first."))
+		return nil
+	}
+
+	snapshot, err := r.Handler.Configuration.GetNetwork(ctx)
+	if err != nil {
+		return err
+	}
+
+	journey, err := r.Handler.PlanSnapshotJourney(ctx, snapshot, *r.last)
+	if err != nil {
+		return err
+	}
+
+	if err := itinerary.Write(r.Output, journey, snapshot, r.Language); err != nil {
+		return err
+	}
+	for _, result := range journey {
+		for _, diagnostic := range result.Diagnostics {
+			r.printf("%s %s\n", i18n.Message(r.Language, "Note:"), render.Diagnostic(r.Language, diagnostic))
+		}
+	}
+	return nil
+}
+
+func (r *REPL) alternatives(ctx context.Context, args []string) error {
+	if r.last == nil {
+		r.printf("%s\n", i18n.Message(r.Language, "There is no route yet, use route first."))
+		return nil
+	}
+
+	snapshot, err := r.Handler.Configuration.GetNetwork(ctx)
+	if err != nil {
+		return err
+	}
+
+	type alternative struct {
+		color  dto.Option
+		result dto.Result
+		err    error
+	}
+	var alternatives []alternative
+	for _, color := range r.colorOptions(snapshot) {
+		config := *r.last
+		config.TrainColor = color.Value
+		result, err := r.Handler.HandleQuery(ctx, config)
+		alternatives = append(alternatives, alternative{color: color, result: result, err: err})
+	}
+
+	sort.SliceStable(alternatives, func(i, j int) bool {
+		a, b := alternatives[i], alternatives[j]
+		if (a.err == nil) != (b.err == nil) {
+			return a.err == nil
+		}
+		return a.err == nil && (a.result.Totals.Distance < b.result.Totals.Distance ||
+			a.result.Totals.Distance == b.result.Totals.Distance && a.result.Totals.Stops < b.result.Totals.Stops)
+	})
+
+	for _, a := range alternatives {
+		if a.err != nil {
+			r.printf("%s: %s\n", a.color.Label, i18n.Error(r.Language, a.err))
+			continue
+		}
+		r.printf(i18n.Message(r.Language, "%s: %s (%d stops, distance %d)")+"\n",
+			a.color.Label, strings.Join(a.result.Stops, " "), a.result.Totals.Stops, a.result.Totals.Distance)
+	}
+	return nil
+}
+
+func (r *REPL) drawMap(ctx context.Context, args []string) error {
+	snapshot, err := r.Handler.Configuration.GetNetwork(ctx)
+	if err != nil {
+		return err
+	}
+
+	return diagram.Of(snapshot).Write(r.Output, diagram.Marks{})
+}
+
+func (r *REPL) reload(ctx context.Context, args []string) error {
+	if r.Store == nil {
+		r.printf("%s\n", i18n.Message(r.Language, "There is no network file to reload."))
+		return nil
+	}
+
+	if err := r.Store.Reload(ctx); err != nil {
+		return err
+	}
+
+	snapshot := r.Store.Current()
+	r.printf(i18n.Message(r.Language, "Network %s loaded with %d stations.")+"\n", snapshot.Version(), len(snapshot.StationNames()))
+	return nil
+}
+
+func (r *REPL) history(ctx context.Context, args []string) error {
+	if r.Editor == nil || r.Editor.History == nil {
+		return nil
+	}
+
+	for i, entry := range r.Editor.History.Entries() {
+		r.printf("%5d  %s\n", i+1, entry)
+	}
+	return nil
+}
+
+func (r *REPL) help(ctx context.Context, args []string) error {
+	for _, c := range commands {
+		r.printf("  %-28s %s\n", c.usage, i18n.Message(r.Language, c.help))
+	}
+	return nil
+}
+
+// complete returns the command names, or the station and color names,
+// starting with the last of words.
+func (r *REPL) complete(ctx context.Context, words []string) []string {
+	word := words[len(words)-1]
+
+	var names []string
+	if len(words) == 1 {
+		for _, c := range commands {
+			names = append(names, c.name)
+		}
+	} else if strings.ToLower(words[0]) == "route" {
+		snapshot, err := r.Handler.Configuration.GetNetwork(ctx)
+		if err != nil {
+			return nil
+		}
+		for _, option := range append(snapshot.StationOptions(r.Language), r.colorOptions(snapshot)...) {
+			names = append(names, option.Label)
+		}
+	}
+
+	prefix := matcher.Normalize(word)
+	var candidates []string
+	for _, name := range names {
+		if strings.HasPrefix(matcher.Normalize(name), prefix) {
+			candidates = append(candidates, name)
+		}
+	}
+	return candidates
+}
+
+func (r *REPL) colorOptions(snapshot *network.Snapshot) []dto.Option {
+	var options []dto.Option
+	for _, color := range snapshot.Colors() {
+		options = append(options, snapshot.ColorOption(color, r.Language))
+	}
+	return options
+}
+
+func (r *REPL) printOption(option dto.Option) {
+	if len(option.Aliases) == 0 {
+		r.printf("%s\n", option.Label)
+		return
+	}
+	r.printf("%s (%s)\n", option.Label, strings.Join(option.Aliases, ", "))
+}
+
+func (r *REPL) printf(format string, args ...interface{}) {
+	fmt.Fprintf(r.Output, format, args...)
+}
+
+func labels(options []dto.Option) []string {
+	result := make([]string, len(options))
+	for i, option := range options {
+		result[i] = option.Label
+	}
+	return result
+}
+
+// split breaks line into words. Double quotes group words with spaces.
+func split(line string) []string {
+	var words []string
+	var word strings.Builder
+	quoted, started := false, false
+
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			started = true
+		case r == ' ' && !quoted:
+			if started {
+				words = append(words, word.String())
+				word.Reset()
+				started = false
+			}
+		default:
+			word.WriteRune(r)
+			started = true
+		}
+	}
+	if started {
+		words = append(words, word.String())
+	}
+
+	return words
+}
@@ -0,0 +1,198 @@
+package repl
+
+import (
+	"buda-challenge/calendar"
+	"buda-challenge/configuration"
+	"buda-challenge/dto"
+	"buda-challenge/handler"
+	"buda-challenge/i18n"
+	"buda-challenge/network"
+	"buda-challenge/processor"
+	"buda-challenge/reader"
+	"buda-challenge/validator"
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const trainNetworkFilePath = "../configuration/train_network.json"
+
+func Test_WhenRouteIsEntered_PrintTheShortestRoute(t *testing.T) {
+	session, output := newREPL(t, i18n.English)
+
+	session.Execute(context.Background(), "route a f verde")
+
+	assert.Equal(t, "Shortest route: A B C G I F\nPath: A → B → C → G → (H) → I → F\nNote: GREEN train passes through H without stopping\n", output.String())
+}
+
+func Test_WhenTheRouteLeavesOutsideTheServiceWindow_StopAtEveryStation(t *testing.T) {
+	session, output := newREPLAt(t, writeNetwork(t, morningGreen()...), i18n.English)
+	session.DepartureTime = "12:00"
+
+	session.Execute(context.Background(), "route A F GREEN")
+
+	assert.Equal(t, "Shortest route: A B C D E F\nNote: GREEN trains stop as WITHOUT COLOR trains at 12:00, outside their service windows\n", output.String())
+}
+
+func Test_WhenTheServiceWindowDoesNotRunOnTheDate_StopAtEveryStation(t *testing.T) {
+	path := writeNetwork(t,
+		dto.Color{Name: "WITHOUT COLOR"},
+		dto.Color{Name: "RED"},
+		dto.Color{Name: "GREEN", Windows: []dto.Window{{From: "07:00", To: "09:00", Calendar: "weekdays"}}})
+	session, output := newREPLAt(t, path, i18n.English)
+	session.Handler.Calendars = weekdays(t)
+	session.DepartureTime = "08:00"
+
+	session.Execute(context.Background(), "route A F GREEN")
+	session.Date = "2026-10-18"
+	session.Execute(context.Background(), "route A F GREEN")
+
+	assert.Equal(t, "Shortest route: A B C G I F\nPath: A → B → C → G → (H) → I → F\nNote: GREEN train passes through H without stopping\n"+
+		"Shortest route: A B C D E F\nNote: GREEN trains stop as WITHOUT COLOR trains at 08:00, outside their service windows\n", output.String())
+}
+
+func Test_WhenRouteHasNoColor_UseATrainWithoutColor(t *testing.T) {
+	session, output := newREPL(t, i18n.English)
+
+	session.Execute(context.Background(), "route A F")
+
+	assert.Equal(t, "Shortest route: A B C D E F\n", output.String())
+}
+
+func Test_WhenRouteHasAnUnknownName_SuggestTheClosest(t *testing.T) {
+	session, output := newREPL(t, i18n.English)
+
+	session.Execute(context.Background(), "route A F GREN")
+
+	assert.Equal(t, "Unknown name \"GREN\".\nDid you mean GREEN?\n", output.String())
+}
+
+func Test_WhenExplainFollowsARoute_PrintTheItinerary(t *testing.T) {
+	session, output := newREPL(t, i18n.Spanish)
+
+	session.Execute(context.Background(), "route A I rojo")
+	output.Reset()
+	session.Execute(context.Background(), "explain")
+
+	assert.True(t, strings.HasPrefix(output.String(), "1. Los trenes ROJO no se detienen en I, así que debe cambiar de tren en H.\n"))
+}
+
+func Test_WhenAlternativesFollowsARoute_CompareEveryColor(t *testing.T) {
+	session, output := newREPL(t, i18n.English)
+
+	session.Execute(context.Background(), "route A F")
+	output.Reset()
+	session.Execute(context.Background(), "alternatives")
+
+	assert.Equal(t, "WITHOUT COLOR: A B C D E F (6 stops, distance 5)\n"+
+		"RED: A B C H F (5 stops, distance 6)\n"+
+		"GREEN: A B C G I F (6 stops, distance 6)\n", output.String())
+}
+
+func Test_WhenMapIsEntered_DrawTheForksAsBranches(t *testing.T) {
+	session, output := newREPL(t, i18n.English)
+
+	session.Execute(context.Background(), "map")
+
+	assert.Equal(t, "[A]---[B]---[C]---[D]---[E]---------[F]\n"+
+		"             |                       |\n"+
+		"             +----[G]---[H]---[I]----+\n", output.String())
+}
+
+func Test_WhenExitIsEntered_LeaveTheShell(t *testing.T) {
+	session, output := newREPL(t, i18n.English)
+
+	assert.True(t, session.Execute(context.Background(), "quit"))
+	assert.False(t, session.Execute(context.Background(), "foo"))
+	assert.Equal(t, "Unknown command \"foo\". Type help to list the commands.\n", output.String())
+}
+
+func Test_WhenTheInputEnds_RunReturns(t *testing.T) {
+	session, output := newREPL(t, i18n.English)
+	history, _ := reader.LoadHistory("")
+	session.Editor = &reader.Editor{Input: strings.NewReader("reload\nhistory\n"), Output: output, History: history}
+
+	err := session.Run(context.Background())
+
+	assert.Nil(t, err)
+	assert.Contains(t, output.String(), "with 9 stations.\n")
+	assert.Contains(t, output.String(), "    1  reload\n    2  history\n")
+}
+
+func Test_WhenCompletingARoute_ReturnStationAndColorNames(t *testing.T) {
+	session, _ := newREPL(t, i18n.Spanish)
+
+	assert.Equal(t, []string{"route", "reload"}, session.complete(context.Background(), []string{"r"}))
+	assert.Equal(t, []string{"VERDE"}, session.complete(context.Background(), []string{"route", "A", "F", "v"}))
+	assert.Len(t, session.complete(context.Background(), []string{"route", ""}), 12)
+}
+
+func Test_GivenQuotedWords_SplitKeepsThemTogether(t *testing.T) {
+	assert.Equal(t, []string{"route", "Los Héroes", "Baquedano"}, split(`route "Los Héroes"  Baquedano`))
+}
+
+func newREPL(t *testing.T, language i18n.Language) (*REPL, *bytes.Buffer) {
+	return newREPLAt(t, trainNetworkFilePath, language)
+}
+
+func newREPLAt(t *testing.T, path string, language i18n.Language) (*REPL, *bytes.Buffer) {
+	fileReader := reader.ReaderImpl{Validator: validator.ValidatorImpl{}}
+	store := network.NewStore(fileReader, path)
+	assert.Nil(t, store.Reload(context.Background()))
+
+	output := &bytes.Buffer{}
+	return &REPL{
+		Handler: handler.Handler{
+			Configuration: configuration.ConfigurationImpl{
+				Reader: fileReader,
+				Store:  store,
+			},
+			Processor: processor.ProcessorImpl{
+				Validator: validator.ValidatorImpl{},
+			},
+		},
+		Store:    store,
+		Output:   output,
+		Language: language,
+	}, output
+}
+
+// writeNetwork writes the test network with the given colors to a temporary
+// file and returns its path.
+func writeNetwork(t *testing.T, colors ...dto.Color) string {
+	content, err := ioutil.ReadFile(trainNetworkFilePath)
+	assert.Nil(t, err)
+	var trainNetwork dto.Network
+	assert.Nil(t, json.Unmarshal(content, &trainNetwork))
+	trainNetwork.Colors = colors
+
+	content, err = json.Marshal(trainNetwork)
+	assert.Nil(t, err)
+	path := filepath.Join(t.TempDir(), "train_network.json")
+	assert.Nil(t, ioutil.WriteFile(path, content, 0644))
+	return path
+}
+
+// morningGreen is a GREEN color that only runs its pattern in the morning.
+func morningGreen() []dto.Color {
+	return []dto.Color{
+		{Name: "WITHOUT COLOR"},
+		{Name: "RED"},
+		{Name: "GREEN", Windows: []dto.Window{{From: "07:00", To: "09:00"}}},
+	}
+}
+
+// weekdays is a calendar running from Monday to Friday.
+func weekdays(t *testing.T) *calendar.Calendars {
+	calendars, err := calendar.New(dto.Calendars{Calendars: []dto.Calendar{{
+		ID:       "weekdays",
+		Weekdays: []string{"monday", "tuesday", "wednesday", "thursday", "friday"},
+	}}})
+	assert.Nil(t, err)
+	return calendars
+}
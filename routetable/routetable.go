@@ -0,0 +1,206 @@
+package routetable
+
+import (
+	"buda-challenge/dto"
+	e "buda-challenge/error"
+	"buda-challenge/network"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync/atomic"
+)
+
+// Solver answers a single query, as handler.Handler does.
+type Solver interface {
+	Solve(ctx context.Context, snapshot *network.Snapshot, config dto.Configuration) (dto.Result, error)
+}
+
+// Table holds the path of every origin, destination and color combination
+// of one network version. Paths are stored as station indexes in a single
+// slice, with stops flagging which of them the train stops at; entry k
+// spans paths[offsets[k]:offsets[k+1]], is empty when the combination has
+// no route and heads to the terminal at towards[k].
+type Table struct {
+	version      string
+	stations     []string
+	colors       []string
+	stationIndex map[string]int
+	colorIndex   map[string]int
+	paths        []uint16
+	stops        []bool
+	offsets      []int32
+	towards      []uint16
+}
+
+// Build solves every combination of snapshot's stations and colors.
+func Build(ctx context.Context, solver Solver, snapshot *network.Snapshot) (*Table, error) {
+	stations := snapshot.StationNames()
+	colors := snapshot.Colors()
+	if len(stations) > math.MaxUint16 {
+		return nil, fmt.Errorf("too many stations to precompute: %d", len(stations))
+	}
+
+	table := &Table{
+		version:      snapshot.Version(),
+		stations:     stations,
+		colors:       colors,
+		stationIndex: indexOf(stations),
+		colorIndex:   indexOf(colors),
+		offsets:      make([]int32, 1, len(stations)*len(stations)*len(colors)+1),
+	}
+
+	for _, initialStation := range stations {
+		for _, finalStation := range stations {
+			for _, color := range colors {
+				result, err := solver.Solve(ctx, snapshot, dto.Configuration{
+					InitialStation: initialStation,
+					FinalStation:   finalStation,
+					TrainColor:     color,
+				})
+				if err != nil && err.Error() != e.ErrorInvalidCombination {
+					return nil, err
+				}
+
+				for _, station := range result.Path {
+					table.paths = append(table.paths, uint16(table.stationIndex[station.Name]))
+					table.stops = append(table.stops, station.Stop)
+				}
+				table.offsets = append(table.offsets, int32(len(table.paths)))
+				table.towards = append(table.towards, uint16(table.stationIndex[result.Towards]))
+			}
+		}
+	}
+
+	return table, nil
+}
+
+func (t *Table) Version() string {
+	return t.version
+}
+
+// Lookup returns the path for the combination and the terminal it heads
+// to. known is false when the table does not cover the stations or color;
+// path is nil when the combination has no route.
+func (t *Table) Lookup(initialStation, finalStation, trainColor string) (path []dto.PathStation, towards string, known bool) {
+	k, known := t.entry(initialStation, finalStation, trainColor)
+	if !known {
+		return nil, "", false
+	}
+
+	for i := t.offsets[k]; i < t.offsets[k+1]; i++ {
+		path = append(path, dto.PathStation{Name: t.stations[t.paths[i]], Stop: t.stops[i]})
+	}
+	if path == nil {
+		return nil, "", true
+	}
+
+	return path, t.stations[t.towards[k]], true
+}
+
+// WriteCSV writes one origin-destination matrix per color. Each cell holds
+// the path joined by spaces, with passed-through stations in parentheses,
+// or is empty when there is no route.
+func (t *Table) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	header := append([]string{"color", "origin"}, t.stations...)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, color := range t.colors {
+		for _, initialStation := range t.stations {
+			row := []string{color, initialStation}
+			for _, finalStation := range t.stations {
+				path, _, _ := t.Lookup(initialStation, finalStation, color)
+				row = append(row, formatPath(path))
+			}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func (t *Table) entry(initialStation, finalStation, trainColor string) (int, bool) {
+	initial, ok := t.stationIndex[initialStation]
+	if !ok {
+		return 0, false
+	}
+	final, ok := t.stationIndex[finalStation]
+	if !ok {
+		return 0, false
+	}
+	color, ok := t.colorIndex[trainColor]
+	if !ok {
+		return 0, false
+	}
+
+	return (initial*len(t.stations)+final)*len(t.colors) + color, true
+}
+
+// Precomputed keeps the table of the latest network version. It is safe for
+// concurrent use.
+type Precomputed struct {
+	Solver Solver
+
+	table atomic.Value
+}
+
+// Rebuild replaces the table with one built from snapshot.
+func (p *Precomputed) Rebuild(ctx context.Context, snapshot *network.Snapshot) error {
+	if p.Solver == nil {
+		return errors.New("route table without solver")
+	}
+
+	table, err := Build(ctx, p.Solver, snapshot)
+	if err != nil {
+		return err
+	}
+
+	p.table.Store(table)
+	return nil
+}
+
+// Table returns the current table, or nil before the first build.
+func (p *Precomputed) Table() *Table {
+	table, _ := p.table.Load().(*Table)
+	return table
+}
+
+// Lookup answers from the current table only when it was built for version.
+func (p *Precomputed) Lookup(version, initialStation, finalStation, trainColor string) ([]dto.PathStation, string, bool) {
+	table := p.Table()
+	if table == nil || table.Version() != version {
+		return nil, "", false
+	}
+
+	return table.Lookup(initialStation, finalStation, trainColor)
+}
+
+func formatPath(path []dto.PathStation) string {
+	names := make([]string, 0, len(path))
+	for _, station := range path {
+		if station.Stop {
+			names = append(names, station.Name)
+		} else {
+			names = append(names, "("+station.Name+")")
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+func indexOf(values []string) map[string]int {
+	index := make(map[string]int, len(values))
+	for i, value := range values {
+		index[value] = i
+	}
+	return index
+}
@@ -0,0 +1,150 @@
+package routetable
+
+import (
+	"buda-challenge/dto"
+	e "buda-challenge/error"
+	"buda-challenge/network"
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+const (
+	stationA           = "A"
+	stationB           = "B"
+	stationC           = "C"
+	trainGreen         = "GREEN"
+	trainWithoutColour = "WITHOUT COLOR"
+)
+
+func Test_GivenANetwork_TableAnswersEveryCombinationAsTheSolver(t *testing.T) {
+	snapshot := getSnapshot(t)
+	solver := &stubSolver{}
+
+	table, err := Build(context.Background(), solver, snapshot)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3*3*2, solver.calls)
+	assert.Equal(t, snapshot.Version(), table.Version())
+
+	path, towards, known := table.Lookup(stationC, stationA, trainWithoutColour)
+	assert.True(t, known)
+	assert.Equal(t, stationA, towards)
+	assert.Equal(t, []dto.PathStation{{Name: stationC, Stop: true}, {Name: stationB, Stop: true}, {Name: stationA, Stop: true}}, path)
+
+	path, _, known = table.Lookup(stationC, stationA, trainGreen)
+	assert.True(t, known)
+	assert.Equal(t, []dto.PathStation{{Name: stationC, Stop: true}, {Name: stationB, Stop: false}, {Name: stationA, Stop: true}}, path)
+
+	path, _, known = table.Lookup(stationA, stationB, trainGreen)
+	assert.True(t, known)
+	assert.Nil(t, path)
+
+	_, _, known = table.Lookup(stationA, "Z", trainGreen)
+	assert.False(t, known)
+}
+
+func Test_WhenSolverFails_BuildReturnsError(t *testing.T) {
+	_, err := Build(context.Background(), &stubSolver{err: errors.New(e.ErrorTimeout)}, getSnapshot(t))
+
+	assert.NotNil(t, err)
+}
+
+func Test_GivenATable_WriteOriginDestinationMatrixPerColor(t *testing.T) {
+	table, _ := Build(context.Background(), &stubSolver{}, getSnapshot(t))
+	var output bytes.Buffer
+
+	err := table.WriteCSV(&output)
+
+	expected := "color,origin,A,B,C\n" +
+		"WITHOUT COLOR,A,A,A B,A B C\n" +
+		"WITHOUT COLOR,B,B A,B,B C\n" +
+		"WITHOUT COLOR,C,C B A,C B,C\n" +
+		"GREEN,A,A,,A (B) C\n" +
+		"GREEN,B,,,\n" +
+		"GREEN,C,C (B) A,,C\n"
+	assert.Nil(t, err)
+	assert.Equal(t, expected, output.String())
+}
+
+func Test_WhenTableWasBuiltForAnotherVersion_PrecomputedDoesNotAnswer(t *testing.T) {
+	precomputed := &Precomputed{Solver: &stubSolver{}}
+	snapshot := getSnapshot(t)
+
+	_, _, known := precomputed.Lookup(snapshot.Version(), stationA, stationC, trainGreen)
+	assert.False(t, known)
+
+	assert.Nil(t, precomputed.Rebuild(context.Background(), snapshot))
+
+	path, towards, known := precomputed.Lookup(snapshot.Version(), stationA, stationC, trainGreen)
+	assert.True(t, known)
+	assert.Len(t, path, 3)
+	assert.Equal(t, stationC, towards)
+
+	_, _, known = precomputed.Lookup("other", stationA, stationC, trainGreen)
+	assert.False(t, known)
+}
+
+// stubSolver walks a straight line A-B-C where GREEN trains pass through B.
+type stubSolver struct {
+	calls int
+	err   error
+}
+
+func (s *stubSolver) Solve(ctx context.Context, snapshot *network.Snapshot, config dto.Configuration) (dto.Result, error) {
+	s.calls++
+	if s.err != nil {
+		return dto.Result{}, s.err
+	}
+
+	line := []string{stationA, stationB, stationC}
+	stops := map[string]bool{stationA: true, stationB: config.TrainColor != trainGreen, stationC: true}
+	if !stops[config.InitialStation] || !stops[config.FinalStation] {
+		return dto.Result{}, errors.New(e.ErrorInvalidCombination)
+	}
+
+	initial, final := -1, -1
+	for i, station := range line {
+		if station == config.InitialStation {
+			initial = i
+		}
+		if station == config.FinalStation {
+			final = i
+		}
+	}
+	if initial < 0 || final < 0 {
+		return dto.Result{}, errors.New(e.ErrorInvalidCombination)
+	}
+
+	towards := stationC
+	if final < initial {
+		towards = stationA
+	}
+
+	var path []dto.PathStation
+	for i := initial; ; {
+		path = append(path, dto.PathStation{Name: line[i], Stop: stops[line[i]]})
+		if i == final {
+			return dto.Result{Path: path, Towards: towards}, nil
+		}
+		if i < final {
+			i++
+		} else {
+			i--
+		}
+	}
+}
+
+func getSnapshot(t *testing.T) *network.Snapshot {
+	snapshot, err := network.NewSnapshot(dto.Network{Stations: []dto.Station{
+		{Name: stationA, TrainColor: trainWithoutColour},
+		{Name: stationB, TrainColor: trainWithoutColour},
+		{Name: stationC, TrainColor: trainGreen},
+	}}, time.Now())
+	assert.Nil(t, err)
+
+	return snapshot
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"buda-challenge/diagram"
+	"buda-challenge/dto"
+	e "buda-challenge/error"
+	"buda-challenge/handler"
+	"buda-challenge/i18n"
+	"buda-challenge/matcher"
+	"buda-challenge/metrics"
+	"buda-challenge/network"
+	"buda-challenge/web"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Server answers route queries over HTTP and exposes the planner metrics.
+type Server struct {
+	Handler  handler.Handler
+	Store    *network.Store
+	Registry *metrics.Registry
+}
+
+// New registers the planner, cache and network metrics in registry and
+// wires them into requestHandler.
+func New(requestHandler handler.Handler, store *network.Store, registry *metrics.Registry) Server {
+	requestHandler.Metrics = metrics.NewPlanner(registry)
+	store.Observe(requestHandler.Metrics)
+
+	registry.Gauge("metro_cache_hit_ratio", "Share of cache lookups that found a route.", func() float64 {
+		if requestHandler.Cache == nil {
+			return 0
+		}
+		stats := requestHandler.Cache.Stats()
+		if stats.Hits+stats.Misses == 0 {
+			return 0
+		}
+		return float64(stats.Hits) / float64(stats.Hits+stats.Misses)
+	})
+	registry.Gauge("metro_network_stations", "Stations in the network being served.", func() float64 {
+		if snapshot := store.Current(); snapshot != nil {
+			return float64(len(snapshot.StationNames()))
+		}
+		return 0
+	})
+	registry.Gauge("metro_network_last_reload_timestamp_seconds", "Unix time the network being served was loaded.", func() float64 {
+		if snapshot := store.Current(); snapshot != nil {
+			return float64(snapshot.LoadedAt().UnixNano()) / 1e9
+		}
+		return 0
+	})
+
+	return Server{Handler: requestHandler, Store: store, Registry: registry}
+}
+
+func (s Server) Routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", s.Registry)
+	mux.HandleFunc("/route", s.route)
+	mux.HandleFunc("/network", s.network)
+	mux.Handle("/", web.Page)
+	return mux
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// route answers GET /route?from=A&to=F&color=GREEN&departure=08:30&date=2026-09-18,
+// where departure and date are optional. Stations and colors may be given by any of their
+// names, ignoring case and accents.
+func (s Server) route(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	config := dto.Configuration{
+		InitialStation: strings.ToUpper(query.Get("from")),
+		FinalStation:   strings.ToUpper(query.Get("to")),
+		TrainColor:     strings.ToUpper(query.Get("color")),
+		DepartureTime:  query.Get("departure"),
+		Date:           query.Get("date"),
+	}
+	snapshot := s.Store.Current()
+	if snapshot != nil {
+		stations := snapshot.StationOptions(i18n.English)
+		if station, ok := matcher.Match(query.Get("from"), stations); ok {
+			config.InitialStation = station.Value
+		}
+		if station, ok := matcher.Match(query.Get("to"), stations); ok {
+			config.FinalStation = station.Value
+		}
+		if color, ok := matcher.Match(query.Get("color"), colorOptions(snapshot, i18n.English)); ok {
+			config.TrainColor = color.Value
+		}
+	}
+
+	result, err := s.Handler.HandleSnapshotQuery(r.Context(), snapshot, config)
+	if err != nil {
+		writeJSON(w, statusOf(err), errorResponse{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+type networkResponse struct {
+	Version  string           `json:"version"`
+	Stations []networkStation `json:"stations"`
+	Colors   []networkColor   `json:"colors"`
+	Edges    []networkEdge    `json:"edges"`
+}
+
+// networkStation is a station with its place on the line diagram.
+type networkStation struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+	Slot  int    `json:"slot"`
+	Row   int    `json:"row"`
+}
+
+type networkColor struct {
+	Value string   `json:"value"`
+	Label string   `json:"label"`
+	Stops []string `json:"stops"`
+}
+
+type networkEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// network answers GET /network?lang=es with the stations laid out as a
+// line diagram and the colors with the stations where they stop.
+func (s Server) network(w http.ResponseWriter, r *http.Request) {
+	snapshot := s.Store.Current()
+	if snapshot == nil {
+		writeJSON(w, http.StatusServiceUnavailable, errorResponse{Error: e.ErrorReadingFile})
+		return
+	}
+
+	language := i18n.English
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		var err error
+		if language, err = i18n.ParseLanguage(lang); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	layout := diagram.Of(snapshot)
+	response := networkResponse{Version: snapshot.Version()}
+	for _, option := range snapshot.StationOptions(language) {
+		node, _ := layout.Node(option.Value)
+		response.Stations = append(response.Stations, networkStation{Value: option.Value, Label: option.Label, Slot: node.Slot, Row: node.Row})
+	}
+	for _, edge := range layout.Edges() {
+		response.Edges = append(response.Edges, networkEdge{From: edge.From, To: edge.To})
+	}
+	for _, option := range colorOptions(snapshot, language) {
+		stops, err := s.Handler.Stops(r.Context(), snapshot, option.Value)
+		if err != nil {
+			writeJSON(w, statusOf(err), errorResponse{Error: err.Error()})
+			return
+		}
+		color := networkColor{Value: option.Value, Label: option.Label, Stops: []string{}}
+		for _, name := range snapshot.StationNames() {
+			if stops[name] {
+				color.Stops = append(color.Stops, name)
+			}
+		}
+		response.Colors = append(response.Colors, color)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func colorOptions(snapshot *network.Snapshot, language i18n.Language) []dto.Option {
+	var options []dto.Option
+	for _, color := range snapshot.Colors() {
+		options = append(options, snapshot.ColorOption(color, language))
+	}
+	return options
+}
+
+func statusOf(err error) int {
+	var timeout e.TimeoutError
+	switch {
+	case errors.As(err, &timeout):
+		return http.StatusGatewayTimeout
+	case err.Error() == e.ErrorInvalidCombination, err.Error() == e.ErrorNotRunning:
+		return http.StatusNotFound
+	case err.Error() == e.ErrorReadingFile:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"buda-challenge/cache"
+	"buda-challenge/configuration"
+	"buda-challenge/dto"
+	"buda-challenge/handler"
+	"buda-challenge/metrics"
+	"buda-challenge/network"
+	"buda-challenge/processor"
+	"buda-challenge/reader"
+	"buda-challenge/validator"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const trainNetworkFilePath = "../configuration/train_network.json"
+
+func Test_WhenRouteIsRequested_ReturnItAsJSON(t *testing.T) {
+	server := newServer(t)
+
+	response := get(t, server, "/route?from=a&to=f&color=green")
+
+	var body dto.Result
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&body))
+	assert.Equal(t, []string{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationG, configuration.StationI, configuration.StationF}, body.Stops)
+}
+
+func Test_WhenCombinationIsInvalid_ReturnNotFound(t *testing.T) {
+	server := newServer(t)
+
+	response := get(t, server, "/route?from=A&to=I&color=RED")
+
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
+
+func Test_WhenStationIsUnknown_ReturnBadRequest(t *testing.T) {
+	server := newServer(t)
+
+	response := get(t, server, "/route?from=Z&to=I&color=RED")
+
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func Test_WhenDepartureTimeIsInvalid_ReturnBadRequest(t *testing.T) {
+	server := newServer(t)
+
+	response := get(t, server, "/route?from=A&to=F&color=GREEN&departure=25:00")
+
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func Test_WhenRouteUsesAnotherLanguage_ResolveItsNames(t *testing.T) {
+	server := newServer(t)
+
+	response := get(t, server, "/route?from=a&to=f&color=verde")
+
+	var body dto.Result
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&body))
+	assert.Equal(t, configuration.TrainGreen, body.TrainColor)
+}
+
+func Test_WhenNetworkIsRequested_ReturnTheDiagramAndTheStopsOfEveryColor(t *testing.T) {
+	server := newServer(t)
+
+	response := get(t, server, "/network?lang=es")
+
+	var body networkResponse
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Nil(t, json.NewDecoder(response.Body).Decode(&body))
+	assert.Len(t, body.Version, 12)
+	assert.Len(t, body.Stations, 9)
+	assert.Contains(t, body.Stations, networkStation{Value: configuration.StationG, Label: configuration.StationG, Slot: 3, Row: 1})
+	assert.Contains(t, body.Edges, networkEdge{From: configuration.StationI, To: configuration.StationF})
+	assert.Equal(t, networkColor{
+		Value: configuration.TrainGreen,
+		Label: "VERDE",
+		Stops: []string{configuration.StationA, configuration.StationB, configuration.StationC, configuration.StationD, configuration.StationE, configuration.StationG, configuration.StationI, configuration.StationF},
+	}, body.Colors[1])
+}
+
+func Test_WhenRootIsRequested_ServeThePageWithoutExternalAssets(t *testing.T) {
+	server := newServer(t)
+
+	response := get(t, server, "/")
+	content, _ := ioutil.ReadAll(response.Body)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "text/html; charset=utf-8", response.Header.Get("Content-Type"))
+	assert.Contains(t, string(content), `<select id="color">`)
+	assert.NotContains(t, string(content), "http://cdn")
+	assert.NotContains(t, string(content), "https://")
+	assert.Equal(t, http.StatusNotFound, get(t, server, "/missing").StatusCode)
+}
+
+func Test_WhenThePageIsRequestedInALanguage_TranslateItsText(t *testing.T) {
+	server := newServer(t)
+
+	response := get(t, server, "/?lang=es")
+	content, _ := ioutil.ReadAll(response.Body)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Contains(t, string(content), `<html lang="es">`)
+	assert.Contains(t, string(content), `<label>Origen <select id="from">`)
+	assert.Contains(t, string(content), `"route":"Ruta más corta:"`)
+	assert.Contains(t, string(content), `"passing":"(pasando por %s sin detenerse)"`)
+	assert.Equal(t, http.StatusBadRequest, get(t, server, "/?lang=xx").StatusCode)
+}
+
+func Test_AfterAnsweringQueries_ExposeTheirMetrics(t *testing.T) {
+	server := newServer(t)
+
+	get(t, server, "/route?from=A&to=F&color=GREEN")
+	get(t, server, "/route?from=A&to=F&color=GREEN")
+	get(t, server, "/route?from=A&to=I&color=RED")
+	get(t, server, "/route?from=Z&to=I&color=RED")
+	response := get(t, server, "/metrics")
+
+	content, _ := ioutil.ReadAll(response.Body)
+	output := string(content)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Contains(t, output, `metro_requests_total{outcome="ok"} 2`)
+	assert.Contains(t, output, `metro_requests_total{outcome="invalid_combination"} 1`)
+	assert.Contains(t, output, `metro_requests_total{outcome="read_error"} 1`)
+	assert.Contains(t, output, `metro_stage_duration_seconds_count{stage="load"} 1`)
+	assert.Contains(t, output, `metro_stage_duration_seconds_count{stage="get_stations"} 2`)
+	assert.Contains(t, output, `metro_stage_duration_seconds_count{stage="route_search"} 2`)
+	assert.Contains(t, output, "metro_cache_hit_ratio 0.3333333333333333")
+	assert.Contains(t, output, "metro_network_stations 9")
+	assert.True(t, strings.Contains(output, "metro_network_last_reload_timestamp_seconds 1"))
+}
+
+func newServer(t *testing.T) *httptest.Server {
+	fileReader := reader.ReaderImpl{Validator: validator.ValidatorImpl{}}
+	store := network.NewStore(fileReader, trainNetworkFilePath)
+
+	requestHandler := handler.Handler{
+		Configuration: configuration.ConfigurationImpl{
+			Reader: fileReader,
+			Store:  store,
+		},
+		Processor: processor.ProcessorImpl{
+			Validator: validator.ValidatorImpl{},
+		},
+		Cache: cache.NewLRU(10),
+	}
+
+	routes := New(requestHandler, store, metrics.NewRegistry()).Routes()
+	assert.Nil(t, store.Reload(context.Background()))
+	server := httptest.NewServer(routes)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func get(t *testing.T, server *httptest.Server, path string) *http.Response {
+	response, err := http.Get(server.URL + path)
+	assert.Nil(t, err)
+	t.Cleanup(func() { response.Body.Close() })
+
+	return response
+}
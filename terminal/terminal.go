@@ -0,0 +1,8 @@
+// Package terminal switches a terminal in and out of raw mode without
+// dependencies outside the standard library.
+package terminal
+
+import "errors"
+
+// ErrUnsupported is returned on platforms where raw mode is not implemented.
+var ErrUnsupported = errors.New("terminal: raw mode is not supported on this platform")
@@ -0,0 +1,20 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package terminal
+
+// IsTerminal reports whether fd refers to a terminal. It is always false
+// where raw mode is not supported.
+func IsTerminal(fd int) bool {
+	return false
+}
+
+// MakeRaw is not supported on this platform.
+func MakeRaw(fd int) (func() error, error) {
+	return nil, ErrUnsupported
+}
+
+// Size is not supported on this platform.
+func Size(fd int) (int, int, error) {
+	return 0, 0, ErrUnsupported
+}
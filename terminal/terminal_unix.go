@@ -0,0 +1,60 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+// +build linux darwin freebsd netbsd openbsd
+
+package terminal
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// IsTerminal reports whether fd refers to a terminal.
+func IsTerminal(fd int) bool {
+	var state syscall.Termios
+	return ioctl(fd, ioctlGetTermios, unsafe.Pointer(&state)) == nil
+}
+
+// MakeRaw puts the terminal referred to by fd in raw mode: input is read
+// byte by byte, without echo or signals, and output is not post-processed.
+// The returned function restores the previous mode.
+func MakeRaw(fd int) (func() error, error) {
+	var previous syscall.Termios
+	if err := ioctl(fd, ioctlGetTermios, unsafe.Pointer(&previous)); err != nil {
+		return nil, err
+	}
+
+	raw := previous
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := ioctl(fd, ioctlSetTermios, unsafe.Pointer(&raw)); err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		return ioctl(fd, ioctlSetTermios, unsafe.Pointer(&previous))
+	}, nil
+}
+
+// Size returns the number of columns and rows of the terminal referred to
+// by fd.
+func Size(fd int) (int, int, error) {
+	var size struct {
+		rows, columns, x, y uint16
+	}
+	if err := ioctl(fd, syscall.TIOCGWINSZ, unsafe.Pointer(&size)); err != nil {
+		return 0, 0, err
+	}
+	return int(size.columns), int(size.rows), nil
+}
+
+func ioctl(fd int, request uintptr, argument unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), request, uintptr(argument)); errno != 0 {
+		return errno
+	}
+	return nil
+}
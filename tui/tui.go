@@ -0,0 +1,296 @@
+// Package tui implements a full-screen terminal interface that draws the
+// network and highlights the route between the stations picked on it.
+package tui
+
+import (
+	"buda-challenge/diagram"
+	"buda-challenge/dto"
+	"buda-challenge/handler"
+	"buda-challenge/i18n"
+	"buda-challenge/network"
+	"buda-challenge/reader"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TUI reads keys from Input and draws on Output, which must be a terminal
+// understanding ANSI escape codes.
+type TUI struct {
+	Handler handler.Handler
+	Input   io.Reader
+	Output  io.Writer
+	// MakeRaw switches the terminal to raw mode and returns the function
+	// restoring it.
+	MakeRaw func() (func() error, error)
+	// Size returns the columns and lines of the terminal. When it is nil
+	// or fails, the terminal is taken to be 80 by 24.
+	Size     func() (int, int, error)
+	Language i18n.Language
+	// DepartureTime, as HH:MM, is given to every route. When empty, every
+	// color runs its own stopping pattern.
+	DepartureTime string
+	// Date, as YYYY-MM-DD, is given to every route so the calendars of the
+	// handler apply. When empty, every color runs every day.
+	Date string
+
+	keys        *reader.KeyReader
+	diagram     *diagram.Diagram
+	snapshot    *network.Snapshot
+	colors      []dto.Option
+	color       int
+	cursor      diagram.Node
+	origin      string
+	destination string
+	stops       map[string]bool
+	result      dto.Result
+	err         error
+}
+
+const (
+	keyCtrlC = 3
+	keyEnter = 13
+)
+
+const (
+	styleReset  = "\x1b[0m"
+	styleCursor = "\x1b[7m"
+	styleRoute  = "\x1b[1;32m"
+	styleChosen = "\x1b[1;4m"
+)
+
+// Run draws the network and answers keys until q or Ctrl+C is pressed.
+func (t *TUI) Run(ctx context.Context) error {
+	if err := t.load(ctx); err != nil {
+		return err
+	}
+
+	if t.MakeRaw != nil {
+		restore, err := t.MakeRaw()
+		if err != nil {
+			return err
+		}
+		defer restore()
+	}
+
+	fmt.Fprint(t.Output, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(t.Output, "\x1b[?25h\x1b[?1049l")
+
+	if t.keys == nil {
+		t.keys = reader.NewKeyReader(t.Input)
+	}
+	for {
+		fmt.Fprint(t.Output, t.Frame())
+
+		key, err := t.keys.ReadKey(ctx)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch key {
+		case 'q', 'Q', keyCtrlC:
+			return nil
+		case reader.KeyLeft, 'h':
+			t.move(0, -1)
+		case reader.KeyRight, 'l':
+			t.move(0, 1)
+		case reader.KeyUp, 'k':
+			t.move(-1, 0)
+		case reader.KeyDown, 'j':
+			t.move(1, 0)
+		case keyEnter, ' ':
+			t.choose(ctx)
+		case 'c', 'C':
+			t.color = (t.color + 1) % len(t.colors)
+			t.update(ctx)
+		}
+	}
+}
+
+// load reads the network and lays it out.
+func (t *TUI) load(ctx context.Context) error {
+	snapshot, err := t.Handler.Configuration.GetNetwork(ctx)
+	if err != nil {
+		return err
+	}
+
+	t.snapshot = snapshot
+	t.diagram = diagram.Of(snapshot)
+	t.cursor = t.diagram.Nodes()[0]
+	t.colors = nil
+	for _, color := range snapshot.Colors() {
+		t.colors = append(t.colors, snapshot.ColorOption(color, t.Language))
+	}
+	t.update(ctx)
+
+	return nil
+}
+
+// move puts the cursor on the closest station in the direction of rows
+// and slots.
+func (t *TUI) move(rows, slots int) {
+	best, found := t.cursor, false
+	distance := func(node diagram.Node) int {
+		return abs(node.Slot-t.cursor.Slot) + 2*abs(node.Row-t.cursor.Row)
+	}
+
+	for _, node := range t.diagram.Nodes() {
+		ahead := (slots != 0 && node.Row == t.cursor.Row && (node.Slot-t.cursor.Slot)*slots > 0) ||
+			(rows != 0 && (node.Row-t.cursor.Row)*rows > 0)
+		if ahead && (!found || distance(node) < distance(best)) {
+			best, found = node, true
+		}
+	}
+
+	t.cursor = best
+}
+
+// choose makes the station under the cursor the origin or, once there is
+// one, the destination. Choosing again starts over.
+func (t *TUI) choose(ctx context.Context) {
+	if t.origin == "" || t.destination != "" {
+		t.origin, t.destination = t.cursor.Name, ""
+	} else {
+		t.destination = t.cursor.Name
+	}
+	t.update(ctx)
+}
+
+// update finds the stops of the current color and the route between the
+// chosen stations.
+func (t *TUI) update(ctx context.Context) {
+	color := t.colors[t.color].Value
+
+	t.stops, _ = t.Handler.Stops(ctx, t.snapshot, color)
+
+	t.result, t.err = dto.Result{}, nil
+	if t.origin != "" && t.destination != "" {
+		t.result, t.err = t.Handler.HandleSnapshotQuery(ctx, t.snapshot, dto.Configuration{
+			InitialStation: t.origin,
+			FinalStation:   t.destination,
+			TrainColor:     color,
+			DepartureTime:  t.DepartureTime,
+			Date:           t.Date,
+		})
+	}
+}
+
+// Frame returns the escape codes drawing the whole screen.
+func (t *TUI) Frame() string {
+	columns, lines := 80, 24
+	if t.Size != nil {
+		if c, l, err := t.Size(); err == nil && c > 0 && l > 0 {
+			columns, lines = c, l
+		}
+	}
+
+	var frame strings.Builder
+	frame.WriteString("\x1b[H\x1b[2J")
+	writeLine := func(text string) {
+		frame.WriteString(text + "\x1b[K\r\n")
+	}
+
+	writeLine(i18n.Message(t.Language, "Arrows move, Enter chooses the origin and the destination, c changes the color, q quits."))
+	writeLine("")
+
+	grid := t.diagram.Cells(diagram.Marks{Stops: t.stops, Route: pathNames(t.result.Path)})
+	width, _ := t.diagram.Size()
+	offset := t.offset(columns, width)
+	for i, row := range grid {
+		if i >= lines-7 {
+			break
+		}
+		writeLine(t.drawRow(row, offset, columns))
+	}
+
+	writeLine("")
+	writeLine(fmt.Sprintf("%s: %s   %s: %s   %s: %s",
+		i18n.Message(t.Language, "Origin"), orDash(t.origin),
+		i18n.Message(t.Language, "Destination"), orDash(t.destination),
+		i18n.Message(t.Language, "Color"), t.colors[t.color].Label))
+
+	switch {
+	case t.err != nil:
+		writeLine(fmt.Sprintf("%s %s", i18n.Message(t.Language, "Error:"), i18n.Error(t.Language, t.err)))
+	case t.destination != "":
+		writeLine(fmt.Sprintf("%s %s", i18n.Message(t.Language, "Shortest route:"), strings.Join(t.result.Stops, " ")))
+	case t.origin != "":
+		writeLine(i18n.Message(t.Language, "Choose the destination."))
+	default:
+		writeLine(i18n.Message(t.Language, "Choose the origin."))
+	}
+
+	return frame.String()
+}
+
+// offset returns the first column to draw so that the cursor stays visible
+// on diagrams wider than the terminal.
+func (t *TUI) offset(columns, width int) int {
+	if width <= columns {
+		return 0
+	}
+
+	offset := t.diagram.Column(t.cursor) - columns/2
+	if offset > width-columns {
+		offset = width - columns
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return offset
+}
+
+func (t *TUI) drawRow(row []diagram.Cell, offset, columns int) string {
+	var line strings.Builder
+	current := ""
+	for i := offset; i < len(row) && i < offset+columns; i++ {
+		cell := row[i]
+
+		style := ""
+		switch {
+		case cell.Station != "" && cell.Station == t.cursor.Name:
+			style = styleCursor
+		case cell.Station != "" && (cell.Station == t.origin || cell.Station == t.destination):
+			style = styleChosen
+		case cell.Route:
+			style = styleRoute
+		}
+
+		if style != current {
+			line.WriteString(styleReset + style)
+			current = style
+		}
+		line.WriteRune(cell.Rune)
+	}
+	if current != "" {
+		line.WriteString(styleReset)
+	}
+
+	return line.String()
+}
+
+func pathNames(path []dto.PathStation) []string {
+	names := make([]string, len(path))
+	for i, station := range path {
+		names[i] = station.Name
+	}
+	return names
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
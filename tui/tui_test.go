@@ -0,0 +1,164 @@
+package tui
+
+import (
+	"buda-challenge/calendar"
+	"buda-challenge/configuration"
+	"buda-challenge/dto"
+	"buda-challenge/handler"
+	"buda-challenge/i18n"
+	"buda-challenge/network"
+	"buda-challenge/processor"
+	"buda-challenge/reader"
+	"buda-challenge/validator"
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const trainNetworkFilePath = "../configuration/train_network.json"
+
+func Test_WhenOriginAndDestinationAreChosen_HighlightTheRoute(t *testing.T) {
+	screen, output := newTUI(t, " \x1b[C\x1b[C\x1b[B\x1b[C\x1b[C\rq")
+
+	err := screen.Run(context.Background())
+
+	assert.Nil(t, err)
+	frame := screen.Frame()
+	assert.Contains(t, frame, "Origin: A   Destination: I   Color: WITHOUT COLOR")
+	assert.Contains(t, frame, "Shortest route: A B C G H I")
+	assert.Contains(t, frame, styleRoute+"---[B]---[C]"+styleReset)
+	assert.Contains(t, frame, styleCursor+"[I]"+styleReset)
+	assert.True(t, strings.HasSuffix(output.String(), "\x1b[?25h\x1b[?1049l"))
+}
+
+func Test_WhenTheColorChanges_MarkItsStopsAndRecomputeTheRoute(t *testing.T) {
+	screen, _ := newTUI(t, " \x1b[C\x1b[C\x1b[B\x1b[C\x1b[C\rcq")
+
+	err := screen.Run(context.Background())
+
+	assert.Nil(t, err)
+	frame := screen.Frame()
+	assert.Contains(t, frame, "Color: GREEN")
+	assert.Contains(t, frame, "Shortest route: A B C G I")
+	assert.Contains(t, frame, "(H)")
+}
+
+func Test_WhenTheColorLeavesOutsideItsServiceWindow_RouteThroughEveryStation(t *testing.T) {
+	screen, _ := newTUIAt(t, writeNetwork(t, morningGreen()...), " \x1b[C\x1b[C\x1b[B\x1b[C\x1b[C\rcq")
+	screen.DepartureTime = "12:00"
+
+	err := screen.Run(context.Background())
+
+	assert.Nil(t, err)
+	frame := screen.Frame()
+	assert.Contains(t, frame, "Color: GREEN")
+	assert.Contains(t, frame, "Shortest route: A B C G H I")
+}
+
+func Test_WhenTheColorDoesNotRunOnTheDate_ShowTheError(t *testing.T) {
+	path := writeNetwork(t,
+		dto.Color{Name: "WITHOUT COLOR"},
+		dto.Color{Name: "RED"},
+		dto.Color{Name: "GREEN", Calendar: "weekdays"})
+	screen, _ := newTUIAt(t, path, " \x1b[C\x1b[C\x1b[B\x1b[C\x1b[C\rcq")
+	screen.Handler.Calendars = weekdays(t)
+	screen.Date = "2026-10-18"
+
+	err := screen.Run(context.Background())
+
+	assert.Nil(t, err)
+	frame := screen.Frame()
+	assert.Contains(t, frame, "Color: GREEN")
+	assert.Contains(t, frame, "Error: train color does not run on that date")
+}
+
+func Test_WhenTheColorDoesNotStopAtTheDestination_ShowTheError(t *testing.T) {
+	screen, _ := newTUI(t, "cc \x1b[B\x1b[C\x1b[C\x1b[C\x1b[C\x1b[C\r")
+	screen.Language = i18n.Spanish
+
+	err := screen.Run(context.Background())
+
+	assert.Nil(t, err)
+	frame := screen.Frame()
+	assert.Contains(t, frame, "Origen: A   Destino: I   Color: ROJO")
+	assert.Contains(t, frame, "Error: combinación inválida")
+}
+
+func Test_WhenTheDiagramIsWiderThanTheTerminal_KeepTheCursorVisible(t *testing.T) {
+	screen, _ := newTUI(t, "\x1b[C\x1b[C\x1b[C\x1b[C\x1b[Cq")
+	screen.Size = func() (int, int, error) { return 20, 24, nil }
+
+	err := screen.Run(context.Background())
+
+	assert.Nil(t, err)
+	assert.Contains(t, screen.Frame(), styleCursor+"[F]")
+	assert.NotContains(t, screen.Frame(), "[A]")
+}
+
+func newTUI(t *testing.T, keys string) (*TUI, *bytes.Buffer) {
+	return newTUIAt(t, trainNetworkFilePath, keys)
+}
+
+func newTUIAt(t *testing.T, path string, keys string) (*TUI, *bytes.Buffer) {
+	fileReader := reader.ReaderImpl{Validator: validator.ValidatorImpl{}}
+	store := network.NewStore(fileReader, path)
+	assert.Nil(t, store.Reload(context.Background()))
+
+	output := &bytes.Buffer{}
+	return &TUI{
+		Handler: handler.Handler{
+			Configuration: configuration.ConfigurationImpl{
+				Reader: fileReader,
+				Store:  store,
+			},
+			Processor: processor.ProcessorImpl{
+				Validator: validator.ValidatorImpl{},
+			},
+		},
+		Input:  strings.NewReader(keys),
+		Output: output,
+		MakeRaw: func() (func() error, error) {
+			return func() error { return nil }, nil
+		},
+	}, output
+}
+
+// writeNetwork writes the test network with the given colors to a temporary
+// file and returns its path.
+func writeNetwork(t *testing.T, colors ...dto.Color) string {
+	content, err := ioutil.ReadFile(trainNetworkFilePath)
+	assert.Nil(t, err)
+	var trainNetwork dto.Network
+	assert.Nil(t, json.Unmarshal(content, &trainNetwork))
+	trainNetwork.Colors = colors
+
+	content, err = json.Marshal(trainNetwork)
+	assert.Nil(t, err)
+	path := filepath.Join(t.TempDir(), "train_network.json")
+	assert.Nil(t, ioutil.WriteFile(path, content, 0644))
+	return path
+}
+
+// morningGreen is a GREEN color that only runs its pattern in the morning.
+func morningGreen() []dto.Color {
+	return []dto.Color{
+		{Name: "WITHOUT COLOR"},
+		{Name: "RED"},
+		{Name: "GREEN", Windows: []dto.Window{{From: "07:00", To: "09:00"}}},
+	}
+}
+
+// weekdays is a calendar running from Monday to Friday.
+func weekdays(t *testing.T) *calendar.Calendars {
+	calendars, err := calendar.New(dto.Calendars{Calendars: []dto.Calendar{{
+		ID:       "weekdays",
+		Weekdays: []string{"monday", "tuesday", "wednesday", "thursday", "friday"},
+	}}})
+	assert.Nil(t, err)
+	return calendars
+}
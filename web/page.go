@@ -0,0 +1,220 @@
+package web
+
+import (
+	"buda-challenge/i18n"
+	"html/template"
+)
+
+// page reads the network from /network, fills the dropdowns and draws the
+// line diagram as SVG, then asks /route for the route on every change. Its
+// text comes translated in a pageText.
+var page = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+  body { font-family: system-ui, sans-serif; margin: 2rem; color: #222; }
+  form { display: flex; gap: 1rem; flex-wrap: wrap; align-items: end; }
+  label { display: flex; flex-direction: column; font-size: .9rem; gap: .25rem; }
+  select { font-size: 1rem; padding: .25rem; }
+  #diagram { margin: 1.5rem 0; overflow-x: auto; }
+  .edge { stroke: #999; stroke-width: 4; fill: none; }
+  .edge.route { stroke: #1a7f37; stroke-width: 8; }
+  .station { stroke: #333; stroke-width: 2; fill: #fff; }
+  .station.stop { fill: #333; }
+  .station.route { stroke: #1a7f37; }
+  .station.stop.route { fill: #1a7f37; }
+  .name { font-size: 13px; text-anchor: middle; }
+  #result { font-size: 1.1rem; }
+  .error { color: #b42318; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<form id="query">
+  <label>{{.Origin}} <select id="from"></select></label>
+  <label>{{.Destination}} <select id="to"></select></label>
+  <label>{{.Color}} <select id="color"></select></label>
+</form>
+<div id="diagram"></div>
+<p id="result"></p>
+<script>
+(function () {
+  "use strict";
+
+  var slotWidth = 90, rowHeight = 70, margin = 40, radius = 9;
+  var svgNS = "http://www.w3.org/2000/svg";
+  var lang = new URLSearchParams(location.search).get("lang") || "";
+  var network = null;
+  var text = {{.Script}};
+
+  function byId(id) { return document.getElementById(id); }
+
+  function fill(select, options) {
+    select.innerHTML = "";
+    options.forEach(function (option) {
+      var element = document.createElement("option");
+      element.value = option.value;
+      element.textContent = option.label;
+      select.appendChild(element);
+    });
+  }
+
+  function station(name) {
+    for (var i = 0; i < network.stations.length; i++) {
+      if (network.stations[i].value === name) { return network.stations[i]; }
+    }
+    return null;
+  }
+
+  function x(node) { return margin + node.slot * slotWidth; }
+  function y(node) { return margin + node.row * rowHeight; }
+
+  // Forks leave downwards then run right; joins run right then go up. The
+  // edge closing a circle line runs back left under the row.
+  function points(from, to) {
+    if (from.row === to.row && to.slot < from.slot) {
+      var under = y(from) + rowHeight / 2;
+      return [[x(from), y(from)], [x(from), under], [x(to), under], [x(to), y(to)]];
+    }
+    if (from.row === to.row) { return [[x(from), y(from)], [x(to), y(to)]]; }
+    if (from.row < to.row) { return [[x(from), y(from)], [x(from), y(to)], [x(to), y(to)]]; }
+    return [[x(from), y(from)], [x(to), y(from)], [x(to), y(to)]];
+  }
+
+  function element(name, attributes) {
+    var node = document.createElementNS(svgNS, name);
+    Object.keys(attributes).forEach(function (key) { node.setAttribute(key, attributes[key]); });
+    return node;
+  }
+
+  function draw(result) {
+    var color = network.colors[byId("color").selectedIndex];
+    var stops = {}, onRoute = {}, routeStations = {};
+    color.stops.forEach(function (name) { stops[name] = true; });
+    var path = result && result.path ? result.path : [];
+    path.forEach(function (step, i) {
+      routeStations[step.name] = true;
+      if (i > 0) {
+        onRoute[path[i - 1].name + "\n" + step.name] = true;
+        onRoute[step.name + "\n" + path[i - 1].name] = true;
+      }
+    });
+
+    var slots = 0, rows = 0, loop = 0;
+    network.stations.forEach(function (node) {
+      slots = Math.max(slots, node.slot + 1);
+      rows = Math.max(rows, node.row + 1);
+    });
+    network.edges.forEach(function (edge) {
+      if (station(edge.to).slot < station(edge.from).slot) { loop = rowHeight / 2; }
+    });
+    var svg = element("svg", {
+      width: 2 * margin + (slots - 1) * slotWidth,
+      height: 2 * margin + (rows - 1) * rowHeight + loop,
+      role: "img",
+      "aria-label": text.diagram
+    });
+
+    network.edges.forEach(function (edge) {
+      var route = onRoute[edge.from + "\n" + edge.to];
+      svg.appendChild(element("polyline", {
+        "class": route ? "edge route" : "edge",
+        points: points(station(edge.from), station(edge.to)).join(" ")
+      }));
+    });
+
+    network.stations.forEach(function (node) {
+      var classes = "station" + (stops[node.value] ? " stop" : "") + (routeStations[node.value] ? " route" : "");
+      var circle = element("circle", { "class": classes, cx: x(node), cy: y(node), r: radius });
+      var title = element("title", {});
+      title.textContent = node.label;
+      circle.appendChild(title);
+      svg.appendChild(circle);
+
+      var name = element("text", { "class": "name", x: x(node), y: y(node) - radius - 6 });
+      name.textContent = node.label;
+      svg.appendChild(name);
+    });
+
+    var container = byId("diagram");
+    container.innerHTML = "";
+    container.appendChild(svg);
+  }
+
+  function show(result) {
+    var output = byId("result");
+    output.className = "";
+    if (result.error) {
+      output.className = "error";
+      output.textContent = result.error;
+      draw(null);
+      return;
+    }
+
+    var route = text.route + " " + result.stops.join(" → ");
+    if (result.passed_through && result.passed_through.length) {
+      route += " " + text.passing.replace("%s", result.passed_through.join(", "));
+    }
+    output.textContent = route;
+    draw(result);
+  }
+
+  function query() {
+    var parameters = new URLSearchParams({
+      from: byId("from").value,
+      to: byId("to").value,
+      color: byId("color").value
+    });
+    fetch("route?" + parameters.toString())
+      .then(function (response) { return response.json(); })
+      .then(show)
+      .catch(function (error) { show({ error: String(error) }); });
+  }
+
+  fetch("network" + (lang ? "?lang=" + encodeURIComponent(lang) : ""))
+    .then(function (response) { return response.json(); })
+    .then(function (loaded) {
+      if (loaded.error) { throw new Error(loaded.error); }
+      network = loaded;
+      fill(byId("from"), network.stations);
+      fill(byId("to"), network.stations);
+      fill(byId("color"), network.colors);
+      byId("to").selectedIndex = network.stations.length - 1;
+      ["from", "to", "color"].forEach(function (id) { byId(id).addEventListener("change", query); });
+      query();
+    })
+    .catch(function (error) { show({ error: String(error) }); });
+})();
+</script>
+</body>
+</html>
+`))
+
+// pageText is the text of page in a language. Script holds the strings the
+// script writes itself.
+type pageText struct {
+	Lang        string
+	Title       string
+	Origin      string
+	Destination string
+	Color       string
+	Script      map[string]string
+}
+
+func newPageText(language i18n.Language) pageText {
+	return pageText{
+		Lang:        string(language),
+		Title:       i18n.Message(language, "Metro route planner"),
+		Origin:      i18n.Message(language, "Origin"),
+		Destination: i18n.Message(language, "Destination"),
+		Color:       i18n.Message(language, "Train color"),
+		Script: map[string]string{
+			"route":   i18n.Message(language, "Shortest route:"),
+			"passing": i18n.Message(language, "(passing %s without stopping)"),
+			"diagram": i18n.Message(language, "Line diagram"),
+		},
+	}
+}
@@ -0,0 +1,30 @@
+// Package web serves the single-page route planner. The page is written
+// inline, with no external assets, so that it works on closed networks.
+package web
+
+import (
+	"buda-challenge/i18n"
+	"net/http"
+)
+
+// Page serves the route planner at the root path, in the language of the
+// lang query parameter, and 404 elsewhere.
+var Page http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	language := i18n.English
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		var err error
+		if language, err = i18n.ParseLanguage(lang); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; style-src 'unsafe-inline'; script-src 'unsafe-inline'")
+	_ = page.Execute(w, newPageText(language))
+})